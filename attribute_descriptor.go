@@ -0,0 +1,9 @@
+package render
+
+// AttributeDescriptor represents a single shader vertex inputs attributes.
+type AttributeDescriptor struct {
+	Name     string
+	Type     uint32
+	Count    int32
+	Location int32
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+type clearBuffer struct {
+	drawBuffer int32
+	typ        uint32
+	f          [4]float32
+	i          [4]int32
+	ui         [4]uint32
+}
+
+// clear clears the bound target as described by the technique.
+func (t *Technique) clear(cache *StateCache) {
+	if t.clearMask == 0 && len(t.clearBuffers) == 0 {
+		return
+	}
+	// depth and stencil are only cleared where writes are enabled, the
+	// technique write masks are restored afterwards
+	if t.clearMask&gl.DEPTH_BUFFER_BIT != 0 {
+		cache.setDepthMask(&depthMask{
+			flag: true,
+		})
+	}
+	if t.clearMask&gl.STENCIL_BUFFER_BIT != 0 {
+		cache.enableStencilWrites()
+	}
+	if t.clearMask != 0 {
+		if t.clearMask&gl.COLOR_BUFFER_BIT != 0 {
+			cache.setClearColor(t.clearColor)
+		}
+		if t.clearMask&gl.DEPTH_BUFFER_BIT != 0 {
+			cache.setClearDepth(t.clearDepth)
+		}
+		if t.clearMask&gl.STENCIL_BUFFER_BIT != 0 {
+			cache.setClearStencil(t.clearStencil)
+		}
+		gl.Clear(t.clearMask)
+	}
+	// clear individual attachments
+	for _, buffer := range t.clearBuffers {
+		switch buffer.typ {
+		case gl.INT:
+			gl.ClearBufferiv(gl.COLOR, buffer.drawBuffer, &buffer.i[0])
+		case gl.UNSIGNED_INT:
+			gl.ClearBufferuiv(gl.COLOR, buffer.drawBuffer, &buffer.ui[0])
+		default:
+			gl.ClearBufferfv(gl.COLOR, buffer.drawBuffer, &buffer.f[0])
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kbirk/render"
+)
+
+// reflection holds the reflected interface of a single shader.
+type reflection struct {
+	name       string
+	paths      []string
+	uniforms   map[string]*render.UniformDescriptor
+	blocks     map[string]*render.UniformBlockDescriptor
+	attributes map[string]*render.AttributeDescriptor
+}
+
+type generator struct {
+	buf bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generate returns the formatted Go source for the reflected shaders.
+func generate(pkg string, args []string, shaders []*reflection) ([]byte, error) {
+	g := &generator{}
+	g.printf("// Code generated by render-gen %s. DO NOT EDIT.\n\n", strings.Join(args, " "))
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n\t\"github.com/kbirk/render\"\n)\n\n")
+	for _, shader := range shaders {
+		g.command(shader)
+		g.blocks(shader)
+		g.attributes(shader)
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return g.buf.Bytes(), fmt.Errorf("failed to format generated source: %v", err)
+	}
+	return src, nil
+}
+
+// command emits a command type with a typed setter per uniform and block.
+func (g *generator) command(shader *reflection) {
+	typ := shader.name + "Command"
+	g.printf("// %s is a render command with typed setters for the uniforms of\n", typ)
+	g.printf("// the %s shader (%s).\n", shader.name, strings.Join(shader.paths, ", "))
+	g.printf("type %s struct {\n\trender.Command\n}\n\n", typ)
+
+	for _, name := range sortedKeys(shader.uniforms) {
+		descriptor := shader.uniforms[name]
+		t := lookupType(descriptor.Type)
+		// arrays are reported with a `[0]` suffix
+		base := strings.TrimSuffix(name, "[0]")
+		goType := t.goType
+		glsl := t.glsl + " " + base
+		if descriptor.Count > 1 || base != name {
+			goType = "[]" + goType
+			glsl += fmt.Sprintf("[%d]", descriptor.Count)
+		}
+		method := "Set" + exported(base)
+		g.printf("// %s sets the `%s` uniform.\n", method, glsl)
+		g.printf("func (c *%s) %s(v %s) {\n", typ, method, goType)
+		g.printf("\tc.Uniform(%q, v)\n}\n\n", base)
+	}
+
+	for _, name := range sortedKeys(shader.blocks) {
+		method := "Set" + exported(name) + "Block"
+		g.printf("// %s sets the buffer slice bound to the `%s` uniform block.\n", method, name)
+		g.printf("func (c *%s) %s(slice *render.UniformBlockSlice) {\n", typ, method)
+		g.printf("\tc.UniformBlock(%q, slice)\n}\n\n", name)
+	}
+}
+
+// blockField is a node of the tree of block members, used to rebuild nested
+// structs from the flattened member names.
+type blockField struct {
+	name   string
+	member *render.UniformBlockMember
+	// count is the array length, 0 if not an array
+	count  int32
+	fields []*blockField
+}
+
+func (f *blockField) child(name string) *blockField {
+	for _, field := range f.fields {
+		if field.name == name {
+			return field
+		}
+	}
+	field := &blockField{
+		name: name,
+	}
+	f.fields = append(f.fields, field)
+	return field
+}
+
+// blocks emits a std140 struct per uniform block.
+func (g *generator) blocks(shader *reflection) {
+	for _, name := range sortedKeys(shader.blocks) {
+		block := shader.blocks[name]
+		root := &blockField{}
+		for _, member := range block.OrderedMembers() {
+			path := strings.TrimPrefix(member.Name, block.Name+".")
+			parts := strings.Split(path, ".")
+			node := root
+			for i, part := range parts {
+				base, index := splitIndex(part)
+				node = node.child(base)
+				if i == len(parts)-1 {
+					node.member = member
+					if index >= 0 {
+						node.count = member.Count
+					}
+					break
+				}
+				if index >= 0 && index+1 > node.count {
+					node.count = index + 1
+				}
+			}
+		}
+		typ := shader.name + exported(name)
+		g.printf("// %s mirrors the std140 layout of the `%s` uniform block of\n", typ, name)
+		g.printf("// the %s shader. Encode it with render.EncodeStd140.\n", shader.name)
+		g.structType(typ, root)
+	}
+}
+
+func (g *generator) structType(typ string, node *blockField) {
+	var nested []*blockField
+	g.printf("type %s struct {\n", typ)
+	for _, field := range node.fields {
+		goType, option := "", ""
+		if field.member != nil {
+			t := lookupType(field.member.Type)
+			goType, option = t.goType, t.option
+			if field.count > 0 && t.scalar {
+				option = "array"
+			}
+		} else {
+			goType = typ + exported(field.name)
+			nested = append(nested, field)
+		}
+		if field.count > 0 {
+			goType = fmt.Sprintf("[%d]%s", field.count, goType)
+		}
+		tag := field.name
+		if option != "" {
+			tag += "," + option
+		}
+		g.printf("\t%s %s `glsl:%q`\n", exported(field.name), goType, tag)
+	}
+	g.printf("}\n\n")
+	for _, field := range nested {
+		nestedType := typ + exported(field.name)
+		g.printf("// %s mirrors the std140 layout of the `%s` struct member.\n", nestedType, field.name)
+		g.structType(nestedType, field)
+	}
+}
+
+// attributes emits the vertex inputs and their locations.
+func (g *generator) attributes(shader *reflection) {
+	if len(shader.attributes) == 0 {
+		return
+	}
+	attributes := make([]*render.AttributeDescriptor, 0, len(shader.attributes))
+	for _, attribute := range shader.attributes {
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Location < attributes[j].Location
+	})
+	g.printf("// %sAttributes are the vertex inputs expected by the %s shader.\n",
+		shader.name, shader.name)
+	g.printf("var %sAttributes = []render.AttributeDescriptor{\n", shader.name)
+	for _, attribute := range attributes {
+		g.printf("\t{Name: %q, Type: 0x%x, Count: %d, Location: %d}, // %s\n",
+			attribute.Name, attribute.Type, attribute.Count, attribute.Location,
+			lookupType(attribute.Type).glsl)
+	}
+	g.printf("}\n\n")
+	g.printf("const (\n")
+	for _, attribute := range attributes {
+		name := shader.name + exported(attribute.Name) + "Location"
+		g.printf("\t// %s is the location of the `%s %s` vertex input.\n",
+			name, lookupType(attribute.Type).glsl, attribute.Name)
+		g.printf("\t%s = %d\n", name, attribute.Location)
+	}
+	g.printf(")\n\n")
+}
+
+// splitIndex splits a name such as `lights[2]` into its base and index,
+// returning an index of -1 if the name is not indexed.
+func splitIndex(name string) (string, int32) {
+	open := strings.LastIndex(name, "[")
+	if open == -1 || !strings.HasSuffix(name, "]") {
+		return name, -1
+	}
+	index, err := strconv.Atoi(name[open+1 : len(name)-1])
+	if err != nil {
+		return name, -1
+	}
+	return name[:open], int32(index)
+}
+
+// exported converts a GLSL name such as `u_model_view` or `lights[0].color`
+// into an exported Go identifier.
+func exported(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case map[string]*render.UniformDescriptor:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string]*render.UniformBlockDescriptor:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/kbirk/render"
+)
+
+func TestExported(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"color", "Color"},
+		{"u_model_view", "UModelView"},
+		{"lights[0].color", "Lights0Color"},
+		{"Material.diffuse", "MaterialDiffuse"},
+		{"_private", "Private"},
+		{"vec3", "Vec3"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if name := exported(test.name); name != test.expected {
+			t.Errorf("exported(%q): expected %q, got %q", test.name, test.expected, name)
+		}
+	}
+}
+
+func TestSplitIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		index int32
+	}{
+		{"lights[2]", "lights", 2},
+		{"weights[0]", "weights", 0},
+		{"color", "color", -1},
+		{"lights[x]", "lights[x]", -1},
+		{"lights[2", "lights[2", -1},
+	}
+	for _, test := range tests {
+		base, index := splitIndex(test.name)
+		if base != test.base || index != test.index {
+			t.Errorf("splitIndex(%q): expected (%q, %d), got (%q, %d)",
+				test.name, test.base, test.index, base, index)
+		}
+	}
+}
+
+// normalize collapses runs of whitespace, so expectations do not depend on
+// the column alignment chosen by gofmt.
+func normalize(src string) string {
+	return strings.Join(strings.Fields(src), " ")
+}
+
+func TestGenerateBlocks(t *testing.T) {
+	members := []*render.UniformBlockMember{
+		{Name: "Scene.ambient", Type: gl.FLOAT_VEC3, Count: 1},
+		{Name: "Scene.weights[0]", Type: gl.FLOAT, Count: 4},
+		{Name: "Scene.rotate", Type: gl.FLOAT_MAT2, Count: 1},
+		{Name: "Scene.lights[0].position", Type: gl.FLOAT_VEC4, Count: 1},
+		{Name: "Scene.lights[0].radius", Type: gl.FLOAT, Count: 1},
+		{Name: "Scene.lights[1].position", Type: gl.FLOAT_VEC4, Count: 1},
+		{Name: "Scene.lights[1].radius", Type: gl.FLOAT, Count: 1},
+		{Name: "Scene.count", Type: gl.INT, Count: 1},
+	}
+	block := &render.UniformBlockDescriptor{
+		Name:    "Scene",
+		Members: make(map[string]*render.UniformBlockMember),
+	}
+	for i, member := range members {
+		member.Order = i
+		block.Members[member.Name] = member
+	}
+	shader := &reflection{
+		name:  "Phong",
+		paths: []string{"phong.vert", "phong.frag"},
+		uniforms: map[string]*render.UniformDescriptor{
+			"model":      {Type: gl.FLOAT_MAT4, Count: 1},
+			"offsets[0]": {Type: gl.FLOAT_VEC2, Count: 3},
+		},
+		blocks: map[string]*render.UniformBlockDescriptor{
+			"Scene": block,
+		},
+	}
+	src, err := generate("shaders", []string{"Phong=phong.vert,phong.frag"}, []*reflection{shader})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, src)
+	}
+	generated := normalize(string(src))
+	expected := []string{
+		"type PhongCommand struct { render.Command }",
+		"func (c *PhongCommand) SetModel(v [16]float32) { c.Uniform(\"model\", v) }",
+		"func (c *PhongCommand) SetOffsets(v [][2]float32) { c.Uniform(\"offsets\", v) }",
+		"func (c *PhongCommand) SetSceneBlock(slice *render.UniformBlockSlice) { c.UniformBlock(\"Scene\", slice) }",
+		"type PhongScene struct {" +
+			" Ambient [3]float32 `glsl:\"ambient\"`" +
+			" Weights [4]float32 `glsl:\"weights,array\"`" +
+			" Rotate [4]float32 `glsl:\"rotate,mat2\"`" +
+			" Lights [2]PhongSceneLights `glsl:\"lights\"`" +
+			" Count int32 `glsl:\"count\"` }",
+		"type PhongSceneLights struct {" +
+			" Position [4]float32 `glsl:\"position\"`" +
+			" Radius float32 `glsl:\"radius\"` }",
+	}
+	for _, e := range expected {
+		if !strings.Contains(generated, e) {
+			t.Errorf("expected generated source to contain:\n%s\ngot:\n%s", e, src)
+		}
+	}
+}
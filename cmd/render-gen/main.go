@@ -0,0 +1,128 @@
+// Command render-gen compiles shaders in a headless GL context, reflects
+// their uniforms, uniform blocks and vertex inputs, and generates Go code
+// with typed uniform setters, std140 block structs and vertex layouts.
+//
+// Each shader is provided as a name followed by its stage files, with stages
+// inferred from their extensions:
+//
+//	//go:generate render-gen -out shaders_gen.go Phong=phong.vert,phong.frag
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/kbirk/render"
+)
+
+func init() {
+	// GL calls must be made from the thread the context was created on
+	runtime.LockOSThread()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("render-gen: ")
+
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	out := flag.String("out", "shaders_gen.go", "output file")
+	dir := flag.String("dir", ".", "directory shader paths are relative to")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: render-gen [flags] Name=a.vert,a.frag ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// run returns rather than exits so its deferred cleanup executes
+	err := run(*pkg, *out, os.DirFS(*dir), flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run reflects the shaders described by args and writes the generated source
+// to out.
+func run(pkg string, out string, fsys fs.FS, args []string) error {
+	err := createContext()
+	if err != nil {
+		return err
+	}
+	defer glfw.Terminate()
+
+	var shaders []*reflection
+	for _, arg := range args {
+		shader, err := reflectShader(fsys, arg)
+		if err != nil {
+			return err
+		}
+		shaders = append(shaders, shader)
+	}
+
+	src, err := generate(pkg, os.Args[1:], shaders)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
+
+// createContext creates an invisible window to provide a GL context.
+func createContext() error {
+	err := glfw.Init()
+	if err != nil {
+		return fmt.Errorf("failed to initialize glfw: %v", err)
+	}
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	window, err := glfw.CreateWindow(1, 1, "render-gen", nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return fmt.Errorf("failed to create context: %v", err)
+	}
+	window.MakeContextCurrent()
+	err = gl.Init()
+	if err != nil {
+		glfw.Terminate()
+		return fmt.Errorf("failed to initialize gl: %v", err)
+	}
+	return nil
+}
+
+// reflectShader compiles the shader described by an argument of the form
+// `Name=a.vert,a.frag` and reflects its interface.
+func reflectShader(fsys fs.FS, arg string) (*reflection, error) {
+	eq := strings.Index(arg, "=")
+	if eq <= 0 || eq == len(arg)-1 {
+		return nil, fmt.Errorf("invalid shader `%s`, expected Name=a.vert,a.frag", arg)
+	}
+	name, paths := arg[:eq], strings.Split(arg[eq+1:], ",")
+	if exported(name) != name {
+		return nil, fmt.Errorf("shader name `%s` is not an exported Go identifier", name)
+	}
+	shader, err := render.NewShaderFromFS(fsys, paths...)
+	if err != nil {
+		return nil, fmt.Errorf("shader `%s`: %v", name, err)
+	}
+	defer shader.Destroy()
+	return &reflection{
+		name:       name,
+		paths:      paths,
+		uniforms:   shader.UniformDescriptors(),
+		blocks:     shader.UniformBlockDescriptors(),
+		attributes: shader.AttributeDescriptors(),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// glType describes how a GL type is represented in generated code.
+type glType struct {
+	// glsl is the GLSL name of the type
+	glsl string
+	// goType is the Go type of a single value
+	goType string
+	// option is the std140 tag option needed to encode the Go type
+	option string
+	// scalar is whether the Go type is a single scalar
+	scalar bool
+}
+
+var glTypes = map[uint32]glType{
+	gl.FLOAT:             {"float", "float32", "", true},
+	gl.FLOAT_VEC2:        {"vec2", "[2]float32", "", false},
+	gl.FLOAT_VEC3:        {"vec3", "[3]float32", "", false},
+	gl.FLOAT_VEC4:        {"vec4", "[4]float32", "", false},
+	gl.DOUBLE:            {"double", "float64", "", true},
+	gl.DOUBLE_VEC2:       {"dvec2", "[2]float64", "", false},
+	gl.DOUBLE_VEC3:       {"dvec3", "[3]float64", "", false},
+	gl.DOUBLE_VEC4:       {"dvec4", "[4]float64", "", false},
+	gl.INT:               {"int", "int32", "", true},
+	gl.INT_VEC2:          {"ivec2", "[2]int32", "", false},
+	gl.INT_VEC3:          {"ivec3", "[3]int32", "", false},
+	gl.INT_VEC4:          {"ivec4", "[4]int32", "", false},
+	gl.UNSIGNED_INT:      {"uint", "uint32", "", true},
+	gl.UNSIGNED_INT_VEC2: {"uvec2", "[2]uint32", "", false},
+	gl.UNSIGNED_INT_VEC3: {"uvec3", "[3]uint32", "", false},
+	gl.UNSIGNED_INT_VEC4: {"uvec4", "[4]uint32", "", false},
+	gl.BOOL:              {"bool", "bool", "", true},
+	gl.BOOL_VEC2:         {"bvec2", "[2]bool", "", false},
+	gl.BOOL_VEC3:         {"bvec3", "[3]bool", "", false},
+	gl.BOOL_VEC4:         {"bvec4", "[4]bool", "", false},
+	gl.FLOAT_MAT2:        {"mat2", "[4]float32", "mat2", false},
+	gl.FLOAT_MAT2x3:      {"mat2x3", "[6]float32", "mat2x3", false},
+	gl.FLOAT_MAT2x4:      {"mat2x4", "[8]float32", "mat2x4", false},
+	gl.FLOAT_MAT3:        {"mat3", "[9]float32", "", false},
+	gl.FLOAT_MAT3x2:      {"mat3x2", "[6]float32", "mat3x2", false},
+	gl.FLOAT_MAT3x4:      {"mat3x4", "[12]float32", "mat3x4", false},
+	gl.FLOAT_MAT4:        {"mat4", "[16]float32", "", false},
+	gl.FLOAT_MAT4x2:      {"mat4x2", "[8]float32", "mat4x2", false},
+	gl.FLOAT_MAT4x3:      {"mat4x3", "[12]float32", "mat4x3", false},
+	gl.DOUBLE_MAT2:       {"dmat2", "[4]float64", "mat2", false},
+	gl.DOUBLE_MAT2x3:     {"dmat2x3", "[6]float64", "mat2x3", false},
+	gl.DOUBLE_MAT2x4:     {"dmat2x4", "[8]float64", "mat2x4", false},
+	gl.DOUBLE_MAT3:       {"dmat3", "[9]float64", "", false},
+	gl.DOUBLE_MAT3x2:     {"dmat3x2", "[6]float64", "mat3x2", false},
+	gl.DOUBLE_MAT3x4:     {"dmat3x4", "[12]float64", "mat3x4", false},
+	gl.DOUBLE_MAT4:       {"dmat4", "[16]float64", "", false},
+	gl.DOUBLE_MAT4x2:     {"dmat4x2", "[8]float64", "mat4x2", false},
+	gl.DOUBLE_MAT4x3:     {"dmat4x3", "[12]float64", "mat4x3", false},
+}
+
+// lookupType returns the representation of a GL type. Any type not listed,
+// such as samplers, is set by texture unit.
+func lookupType(typ uint32) glType {
+	t, ok := glTypes[typ]
+	if !ok {
+		return glType{"sampler", "int32", "", true}
+	}
+	return t
+}
@@ -1,9 +1,14 @@
 package render
 
+import (
+	"fmt"
+)
+
 // Command represents a render command.
 type Command struct {
 	uniforms   map[string]interface{}
 	textures   map[uint32]*Texture
+	blocks     map[string]*UniformBlockSlice
 	renderable *Renderable
 }
 
@@ -23,20 +28,40 @@ func (c *Command) Texture(location uint32, texture *Texture) {
 	c.textures[location] = texture
 }
 
+// UniformBlock sets a uniform buffer slice to be bound to a uniform block.
+func (c *Command) UniformBlock(name string, slice *UniformBlockSlice) {
+	if c.blocks == nil {
+		c.blocks = make(map[string]*UniformBlockSlice)
+	}
+	c.blocks[name] = slice
+}
+
 // Renderable sets a renderable to be drawn.
 func (c *Command) Renderable(renderable *Renderable) {
 	c.renderable = renderable
 }
 
 // Execute executes the render command.
-func (c *Command) Execute(shader *Shader) error {
+func (c *Command) Execute(program Program) error {
 	// bind textures
 	for location, texture := range c.textures {
 		texture.Bind(location)
 	}
+	// bind uniform blocks
+	for name, slice := range c.blocks {
+		descriptor, ok := program.UniformBlockDescriptors()[name]
+		if !ok {
+			return fmt.Errorf("uniform block `%s` was not recognized", name)
+		}
+		if slice.size < descriptor.Size {
+			return fmt.Errorf("slice of %d bytes cannot hold uniform block "+
+				"`%s` of %d bytes", slice.size, name, descriptor.Size)
+		}
+		slice.Bind(descriptor.Binding)
+	}
 	// set uniforms
 	for name, value := range c.uniforms {
-		err := shader.SetUniform(name, value)
+		err := program.SetUniform(name, value)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// FragmentOutputDescriptor represents a single fragment shader outputs
+// attributes.
+type FragmentOutputDescriptor struct {
+	Name string
+	// Type is the GL type of the output, such as gl.FLOAT_VEC4.
+	Type uint32
+	// Count is the array size of the output, 1 if not an array.
+	Count int32
+	// Location is the color number the output is written to. Arrays occupy
+	// consecutive color numbers.
+	Location int32
+}
+
+var (
+	commentRegex = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	// `layout(location = 0) out vec4 color;` or `out vec4 color, normal[2];`
+	outputRegex = regexp.MustCompile(`(?m)^\s*(?:layout\s*\([^)]*\)\s*)?` +
+		`(?:(?:flat|smooth|noperspective|invariant|highp|mediump|lowp)\s+)*` +
+		`out\s+(\w+)\s*(?:\[\s*(\d+)\s*\])?\s+([^;]+);`)
+	// a single declarator of a declaration, such as `colors[2]`
+	declaratorRegex = regexp.MustCompile(`^\s*(\w+)\s*(?:\[\s*(\d+)\s*\])?\s*$`)
+)
+
+// outputDeclaration is a fragment output declared in the shader source.
+type outputDeclaration struct {
+	name  string
+	typ   string
+	count int32
+}
+
+// parseOutputs returns the fragment outputs declared in the provided source,
+// in declared order.
+func parseOutputs(source string) []outputDeclaration {
+	var declarations []outputDeclaration
+	source = commentRegex.ReplaceAllString(source, "")
+	for _, match := range outputRegex.FindAllStringSubmatch(source, -1) {
+		// an array type such as `vec4[2]` applies to every declarator
+		typeCount := int32(1)
+		if match[2] != "" {
+			n, _ := strconv.Atoi(match[2])
+			typeCount = int32(n)
+		}
+		for _, declarator := range strings.Split(match[3], ",") {
+			parts := declaratorRegex.FindStringSubmatch(declarator)
+			if parts == nil {
+				continue
+			}
+			count := typeCount
+			if parts[2] != "" {
+				n, _ := strconv.Atoi(parts[2])
+				count = int32(n)
+			}
+			declarations = append(declarations, outputDeclaration{
+				name:  parts[1],
+				typ:   match[1],
+				count: count,
+			})
+		}
+	}
+	return declarations
+}
+
+var outputTypes = map[string]uint32{
+	"float": gl.FLOAT,
+	"vec2":  gl.FLOAT_VEC2,
+	"vec3":  gl.FLOAT_VEC3,
+	"vec4":  gl.FLOAT_VEC4,
+	"int":   gl.INT,
+	"ivec2": gl.INT_VEC2,
+	"ivec3": gl.INT_VEC3,
+	"ivec4": gl.INT_VEC4,
+	"uint":  gl.UNSIGNED_INT,
+	"uvec2": gl.UNSIGNED_INT_VEC2,
+	"uvec3": gl.UNSIGNED_INT_VEC3,
+	"uvec4": gl.UNSIGNED_INT_VEC4,
+}
+
+// queryOutputs reflects the active fragment outputs. GL 4.1 cannot enumerate
+// outputs, so they are parsed from the fragment source and then queried.
+func (s *Shader) queryOutputs() {
+	s.outputs = make(map[string]*FragmentOutputDescriptor)
+	for _, stage := range s.stages {
+		if stage.Type != gl.FRAGMENT_SHADER {
+			continue
+		}
+		source := stage.Source
+		if !stage.loaded {
+			var err error
+			source, err = loadShaderSource(stage.Source)
+			if err != nil {
+				return
+			}
+		}
+		for _, declaration := range parseOutputs(source) {
+			name := declaration.name
+			location := gl.GetFragDataLocation(s.id, gl.Str(name+"\x00"))
+			if location == -1 {
+				// declared but inactive, or excluded by the preprocessor
+				continue
+			}
+			s.outputs[name] = &FragmentOutputDescriptor{
+				Name:     name,
+				Type:     outputTypes[declaration.typ],
+				Count:    declaration.count,
+				Location: location,
+			}
+		}
+	}
+}
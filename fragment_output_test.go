@@ -0,0 +1,81 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []outputDeclaration
+	}{
+		{
+			name:     "single",
+			source:   "out vec4 color;",
+			expected: []outputDeclaration{{"color", "vec4", 1}},
+		},
+		{
+			name: "layout and qualifiers",
+			source: "layout(location = 1) out vec3 normal;\n" +
+				"  flat out ivec2 id;",
+			expected: []outputDeclaration{
+				{"normal", "vec3", 1},
+				{"id", "ivec2", 1},
+			},
+		},
+		{
+			name:     "array",
+			source:   "out vec4 colors [ 3 ];",
+			expected: []outputDeclaration{{"colors", "vec4", 3}},
+		},
+		{
+			name:   "comma separated",
+			source: "out vec4 albedo, normal[2], position;",
+			expected: []outputDeclaration{
+				{"albedo", "vec4", 1},
+				{"normal", "vec4", 2},
+				{"position", "vec4", 1},
+			},
+		},
+		{
+			name:   "comma separated across lines",
+			source: "out uvec4 id,\n\tmask;",
+			expected: []outputDeclaration{
+				{"id", "uvec4", 1},
+				{"mask", "uvec4", 1},
+			},
+		},
+		{
+			name:   "array type",
+			source: "out vec4[2] a, b;",
+			expected: []outputDeclaration{
+				{"a", "vec4", 2},
+				{"b", "vec4", 2},
+			},
+		},
+		{
+			name: "comments",
+			source: "// out vec4 commented;\n" +
+				"/* out vec4 block;\n out vec4 comment; */\n" +
+				"out float depth; // trailing",
+			expected: []outputDeclaration{{"depth", "float", 1}},
+		},
+		{
+			name: "not outputs",
+			source: "in vec4 color;\n" +
+				"uniform vec4 tint;\n" +
+				"vec4 layout_out;\n" +
+				"void main() { color = vec4(0.0); }",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			declarations := parseOutputs(test.source)
+			if !reflect.DeepEqual(declarations, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, declarations)
+			}
+		})
+	}
+}
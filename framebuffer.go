@@ -10,6 +10,8 @@ import (
 type FrameBuffer struct {
 	id       uint32
 	textures map[uint32]*Texture
+	// drawBuffers are the draw buffers last set, nil if unset
+	drawBuffers []uint32
 }
 
 // NewFrameBuffer instantiates and returns a new framebuffer instance.
@@ -22,7 +24,8 @@ func NewFrameBuffer() *FrameBuffer {
 	}
 }
 
-// Bind binds the framebuffer object.
+// Bind binds the framebuffer object. Binding outside of a StateCache leaves
+// the cache stale, so call Invalidate on it before its next use.
 func (f *FrameBuffer) Bind() {
 	gl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
 }
@@ -54,17 +57,50 @@ func (f *FrameBuffer) UnbindForRead() {
 
 // SetDrawBuffers sets the draw buffers for the framebuffer object.
 func (f *FrameBuffer) SetDrawBuffers(buffers []uint32) {
+	f.drawBuffers = append([]uint32(nil), buffers...)
 	gl.DrawBuffers(int32(len(buffers)), &buffers[0])
 }
 
+// SetDrawBuffersForShader sets the draw buffers for the framebuffer object so
+// that each fragment output of the shader is written to the color attachment
+// matching its location. Color numbers without an output are set to gl.NONE.
+// The framebuffer is bound through the cache of the current context.
+func (f *FrameBuffer) SetDrawBuffersForShader(cache *StateCache, shader *Shader) error {
+	outputs := shader.FragmentOutputs()
+	if len(outputs) == 0 {
+		return fmt.Errorf("shader has no active fragment outputs")
+	}
+	var buffers []uint32
+	for _, output := range outputs {
+		for i := int32(0); i < output.Count; i++ {
+			location := output.Location + i
+			attachment := gl.COLOR_ATTACHMENT0 + uint32(location)
+			_, ok := f.textures[attachment]
+			if !ok {
+				return fmt.Errorf("fragment output `%s` at location %d has "+
+					"no texture attached to attachment `%d`", output.Name,
+					location, attachment)
+			}
+			for int32(len(buffers)) <= location {
+				buffers = append(buffers, gl.NONE)
+			}
+			buffers[location] = attachment
+		}
+	}
+	cache.bindFrameBuffer(f)
+	f.SetDrawBuffers(buffers)
+	return nil
+}
+
 // AttachTexture attaches the provided texture to the provided attachment id.
-func (f *FrameBuffer) AttachTexture(attachment uint32, texture *Texture) error {
+// The framebuffer is bound through the cache of the current context.
+func (f *FrameBuffer) AttachTexture(cache *StateCache, attachment uint32, texture *Texture) error {
 	_, ok := f.textures[attachment]
 	if ok {
 		return fmt.Errorf("texture already attached to attachment `%d`",
 			attachment)
 	}
-	f.Bind()
+	cache.bindFrameBuffer(f)
 	gl.FramebufferTexture2D(
 		gl.FRAMEBUFFER,
 		attachment,
@@ -72,7 +108,6 @@ func (f *FrameBuffer) AttachTexture(attachment uint32, texture *Texture) error {
 		texture.ID(),
 		0)
 	err := f.checkAttachmentError()
-	f.Unbind()
 	if err == nil {
 		f.textures[attachment] = texture
 	}
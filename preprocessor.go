@@ -0,0 +1,313 @@
+package render
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// PreprocessedSource represents GLSL source with all includes resolved.
+type PreprocessedSource struct {
+	// Source is the resolved GLSL source.
+	Source string
+	// Files maps the source string numbers used in the emitted `#line`
+	// directives to the names of the files they refer to.
+	Files []string
+}
+
+// Preprocessor resolves `#include` directives in GLSL source against a
+// filesystem.
+type Preprocessor struct {
+	fsys fs.FS
+}
+
+// NewPreprocessor instantiates and returns a new preprocessor which resolves
+// includes against the provided filesystem.
+func NewPreprocessor(fsys fs.FS) *Preprocessor {
+	return &Preprocessor{
+		fsys: fsys,
+	}
+}
+
+// Process loads the named file from the filesystem and resolves its includes.
+func (p *Preprocessor) Process(name string) (*PreprocessedSource, error) {
+	raw, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.ProcessSource(name, string(raw))
+}
+
+// ProcessSource resolves the includes of the provided source. The name is
+// used to resolve relative includes and to identify the source in `#line`
+// directives.
+func (p *Preprocessor) ProcessSource(name string, source string) (*PreprocessedSource, error) {
+	state := &preprocessState{
+		fsys:    p.fsys,
+		indices: make(map[string]int),
+		once:    make(map[string]bool),
+		guards:  make(map[string]bool),
+	}
+	err := state.process(path.Clean(name), source, true)
+	if err != nil {
+		return nil, err
+	}
+	return &PreprocessedSource{
+		Source: state.out.String(),
+		Files:  state.files,
+	}, nil
+}
+
+// Stage loads the named file, resolves its includes and returns it as a
+// shader stage of the provided type.
+func (p *Preprocessor) Stage(typ uint32, name string) (*ShaderStage, error) {
+	src, err := p.Process(name)
+	if err != nil {
+		return nil, err
+	}
+	return &ShaderStage{
+		Type:         typ,
+		Source:       src.Source,
+		Files:        src.Files,
+		loaded:       true,
+		preprocessor: p,
+		path:         name,
+	}, nil
+}
+
+type preprocessState struct {
+	fsys    fs.FS
+	out     strings.Builder
+	files   []string
+	indices map[string]int
+	stack   []string
+	once    map[string]bool
+	guards  map[string]bool
+}
+
+func (s *preprocessState) index(name string) int {
+	index, ok := s.indices[name]
+	if !ok {
+		index = len(s.files)
+		s.indices[name] = index
+		s.files = append(s.files, name)
+	}
+	return index
+}
+
+func (s *preprocessState) process(name string, source string, root bool) error {
+	lines := strings.Split(strings.Replace(source, "\r\n", "\n", -1), "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// skip files that have already been included behind a guard
+	guard := includeGuard(lines)
+	if guard != "" {
+		if s.guards[guard] {
+			return nil
+		}
+		s.guards[guard] = true
+	}
+	if pragmaOnce(lines) {
+		if s.once[name] {
+			return nil
+		}
+		s.once[name] = true
+	}
+
+	// check for include cycles
+	for i, parent := range s.stack {
+		if parent == name {
+			cycle := append(append([]string{}, s.stack[i:]...), name)
+			return fmt.Errorf("include cycle detected: %s",
+				strings.Join(cycle, " -> "))
+		}
+	}
+	s.stack = append(s.stack, name)
+	defer func() {
+		s.stack = s.stack[:len(s.stack)-1]
+	}()
+
+	index := s.index(name)
+	inComment := false
+	for i, line := range lines {
+		lineNum := i + 1
+		startsInComment := inComment
+		inComment = endsInComment(line, inComment)
+		if startsInComment {
+			s.writeLine(line)
+			continue
+		}
+		directive, arg := parseDirective(line)
+		switch directive {
+		case "version":
+			if !root {
+				return fmt.Errorf("%s:%d: `#version` is only allowed in the root file",
+					name, lineNum)
+			}
+		case "pragma":
+			if arg == "once" {
+				// blank the line to preserve line numbers
+				s.writeLine("")
+				continue
+			}
+		case "include":
+			include, err := parseIncludePath(arg)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %v", name, lineNum, err)
+			}
+			resolved, raw, err := s.resolve(name, include)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %v", name, lineNum, err)
+			}
+			// an included file is referenced by its own source string number
+			s.writeLine(fmt.Sprintf("#line 1 %d", s.index(resolved)))
+			err = s.process(resolved, raw, false)
+			if err != nil {
+				return err
+			}
+			// resume numbering of the including file on the next line
+			s.writeLine(fmt.Sprintf("#line %d %d", lineNum+1, index))
+			continue
+		}
+		s.writeLine(line)
+	}
+	return nil
+}
+
+func (s *preprocessState) writeLine(line string) {
+	s.out.WriteString(line)
+	s.out.WriteString("\n")
+}
+
+func (s *preprocessState) resolve(parent string, include string) (string, string, error) {
+	// first try relative to the including file, then from the root
+	candidates := []string{
+		path.Join(path.Dir(parent), include),
+		path.Clean(include),
+	}
+	for _, candidate := range candidates {
+		raw, err := fs.ReadFile(s.fsys, candidate)
+		if err == nil {
+			return candidate, string(raw), nil
+		}
+	}
+	return "", "", fmt.Errorf("included file `%s` not found", include)
+}
+
+// parseDirective returns the directive name and argument of a preprocessor
+// line, or empty strings if the line is not a directive.
+func parseDirective(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#") {
+		return "", ""
+	}
+	trimmed = strings.TrimSpace(trimmed[1:])
+	end := strings.IndexAny(trimmed, " \t\"<")
+	if end == -1 {
+		return trimmed, ""
+	}
+	arg := trimmed[end:]
+	// strip trailing line comments
+	if comment := strings.Index(arg, "//"); comment != -1 {
+		arg = arg[:comment]
+	}
+	return trimmed[:end], strings.TrimSpace(arg)
+}
+
+func parseIncludePath(arg string) (string, error) {
+	if len(arg) >= 2 {
+		if (arg[0] == '"' && arg[len(arg)-1] == '"') ||
+			(arg[0] == '<' && arg[len(arg)-1] == '>') {
+			return arg[1 : len(arg)-1], nil
+		}
+	}
+	return "", fmt.Errorf("malformed `#include` directive `%s`", arg)
+}
+
+// includeGuard returns the macro of a classic `#ifndef` / `#define` /
+// `#endif` include guard wrapping the whole file, if one exists.
+func includeGuard(lines []string) string {
+	var significant []string
+	inComment := false
+	for _, line := range lines {
+		startsInComment := inComment
+		inComment = endsInComment(line, inComment)
+		trimmed := strings.TrimSpace(line)
+		if startsInComment || trimmed == "" ||
+			strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") {
+			continue
+		}
+		significant = append(significant, trimmed)
+	}
+	if len(significant) < 3 {
+		return ""
+	}
+	directive, guard := parseDirective(significant[0])
+	if directive != "ifndef" || guard == "" {
+		return ""
+	}
+	directive, arg := parseDirective(significant[1])
+	if directive != "define" || arg != guard {
+		return ""
+	}
+	// the conditional opened on the first line must close on the last
+	depth := 0
+	for i, line := range significant {
+		directive, _ := parseDirective(line)
+		switch directive {
+		case "if", "ifdef", "ifndef":
+			depth++
+		case "endif":
+			depth--
+			if depth == 0 && i != len(significant)-1 {
+				return ""
+			}
+		}
+	}
+	if depth != 0 {
+		return ""
+	}
+	return guard
+}
+
+// pragmaOnce returns whether the file contains a `#pragma once` directive.
+func pragmaOnce(lines []string) bool {
+	inComment := false
+	for _, line := range lines {
+		startsInComment := inComment
+		inComment = endsInComment(line, inComment)
+		if startsInComment {
+			continue
+		}
+		directive, arg := parseDirective(line)
+		if directive == "pragma" && arg == "once" {
+			return true
+		}
+	}
+	return false
+}
+
+// endsInComment returns whether the end of the line is inside a block
+// comment, given whether the start of the line was.
+func endsInComment(line string, inComment bool) bool {
+	for i := 0; i < len(line)-1; i++ {
+		if inComment {
+			if line[i] == '*' && line[i+1] == '/' {
+				inComment = false
+				i++
+			}
+			continue
+		}
+		if line[i] == '/' && line[i+1] == '/' {
+			return false
+		}
+		if line[i] == '/' && line[i+1] == '*' {
+			inComment = true
+			i++
+		}
+	}
+	return inComment
+}
@@ -0,0 +1,207 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPreprocessorProcess(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  fstest.MapFS
+		root   string
+		source string
+		names  []string
+		err    string
+	}{
+		{
+			name: "no includes",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("#version 410\nvoid main() {}\n")},
+			},
+			root:   "main.frag",
+			source: "#version 410\nvoid main() {}\n",
+			names:  []string{"main.frag"},
+		},
+		{
+			name: "relative include",
+			files: fstest.MapFS{
+				"shaders/main.frag":  {Data: []byte("#version 410\n#include \"light.glsl\"\nvoid main() {}\n")},
+				"shaders/light.glsl": {Data: []byte("float light;\n")},
+			},
+			root: "shaders/main.frag",
+			source: "#version 410\n" +
+				"#line 1 1\n" +
+				"float light;\n" +
+				"#line 3 0\n" +
+				"void main() {}\n",
+			names: []string{"shaders/main.frag", "shaders/light.glsl"},
+		},
+		{
+			name: "root include",
+			files: fstest.MapFS{
+				"shaders/main.frag": {Data: []byte("#include <common/math.glsl>\n")},
+				"common/math.glsl":  {Data: []byte("float pi;\n")},
+			},
+			root: "shaders/main.frag",
+			source: "#line 1 1\n" +
+				"float pi;\n" +
+				"#line 2 0\n",
+			names: []string{"shaders/main.frag", "common/math.glsl"},
+		},
+		{
+			name: "include guard",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("#include \"a.glsl\"\n#include \"a.glsl\"\n")},
+				"a.glsl":    {Data: []byte("#ifndef A\n#define A\nfloat a;\n#endif\n")},
+			},
+			root: "main.frag",
+			source: "#line 1 1\n" +
+				"#ifndef A\n#define A\nfloat a;\n#endif\n" +
+				"#line 2 0\n" +
+				"#line 1 1\n" +
+				"#line 3 0\n",
+			names: []string{"main.frag", "a.glsl"},
+		},
+		{
+			name: "pragma once",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("#include \"a.glsl\"\n#include \"a.glsl\"\n")},
+				"a.glsl":    {Data: []byte("#pragma once\nfloat a;\n")},
+			},
+			root: "main.frag",
+			source: "#line 1 1\n" +
+				"\nfloat a;\n" +
+				"#line 2 0\n" +
+				"#line 1 1\n" +
+				"#line 3 0\n",
+			names: []string{"main.frag", "a.glsl"},
+		},
+		{
+			name: "commented include",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("/*\n#include \"missing.glsl\"\n*/\n")},
+			},
+			root:   "main.frag",
+			source: "/*\n#include \"missing.glsl\"\n*/\n",
+			names:  []string{"main.frag"},
+		},
+		{
+			name: "cycle",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("#include \"a.glsl\"\n")},
+				"a.glsl":    {Data: []byte("#include \"b.glsl\"\n")},
+				"b.glsl":    {Data: []byte("#include \"a.glsl\"\n")},
+			},
+			root: "main.frag",
+			err:  "include cycle detected: a.glsl -> b.glsl -> a.glsl",
+		},
+		{
+			name: "missing include",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("\n#include \"missing.glsl\"\n")},
+			},
+			root: "main.frag",
+			err:  "main.frag:2: included file `missing.glsl` not found",
+		},
+		{
+			name: "malformed include",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("#include missing.glsl\n")},
+			},
+			root: "main.frag",
+			err:  "main.frag:1: malformed `#include` directive `missing.glsl`",
+		},
+		{
+			name: "version in include",
+			files: fstest.MapFS{
+				"main.frag": {Data: []byte("#include \"a.glsl\"\n")},
+				"a.glsl":    {Data: []byte("#version 410\n")},
+			},
+			root: "main.frag",
+			err:  "a.glsl:1: `#version` is only allowed in the root file",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, err := NewPreprocessor(test.files).Process(test.root)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if src.Source != test.source {
+				t.Errorf("expected source:\n%s\ngot:\n%s", test.source, src.Source)
+			}
+			if !reflect.DeepEqual(src.Files, test.names) {
+				t.Errorf("expected files %v, got %v", test.names, src.Files)
+			}
+		})
+	}
+}
+
+func TestPreprocessorLineMapping(t *testing.T) {
+	files := fstest.MapFS{
+		"main.frag": {Data: []byte("#version 410\n#include \"a.glsl\"\nline3\n")},
+		"a.glsl":    {Data: []byte("a1\n#include \"b.glsl\"\na3\n")},
+		"b.glsl":    {Data: []byte("b1\n")},
+	}
+	src, err := NewPreprocessor(files).Process("main.frag")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// follow the #line directives to map each emitted line to its origin
+	line, file := 1, 0
+	origins := make(map[string]string)
+	for _, out := range strings.Split(strings.TrimSuffix(src.Source, "\n"), "\n") {
+		var l, f int
+		if n, _ := fmt.Sscanf(out, "#line %d %d", &l, &f); n == 2 {
+			line, file = l, f
+			continue
+		}
+		origins[out] = src.Files[file] + ":" + strconv.Itoa(line)
+		line++
+	}
+	expected := map[string]string{
+		"#version 410": "main.frag:1",
+		"a1":           "a.glsl:1",
+		"b1":           "b.glsl:1",
+		"a3":           "a.glsl:3",
+		"line3":        "main.frag:3",
+	}
+	if !reflect.DeepEqual(origins, expected) {
+		t.Errorf("expected origins %v, got %v", expected, origins)
+	}
+}
+
+func TestIncludeGuard(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		guard  string
+	}{
+		{"guard", "#ifndef A\n#define A\nfloat a;\n#endif", "A"},
+		{"leading comments", "// header\n/* block\n*/\n#ifndef A\n#define A\n#endif", "A"},
+		{"nested conditional", "#ifndef A\n#define A\n#ifdef B\n#endif\n#endif", "A"},
+		{"mismatched define", "#ifndef A\n#define B\n#endif", ""},
+		{"trailing code", "#ifndef A\n#define A\n#endif\nfloat a;", ""},
+		{"unterminated", "#ifndef A\n#define A\nfloat a;", ""},
+		{"ifdef", "#ifdef A\n#define A\n#endif", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			guard := includeGuard(strings.Split(test.source, "\n"))
+			if guard != test.guard {
+				t.Errorf("expected guard %q, got %q", test.guard, guard)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package render
+
+// Program represents anything commands can be executed against, either a
+// single Shader or a ProgramPipeline of separable shaders.
+type Program interface {
+	// Use activates the program.
+	Use()
+	// SetUniform buffers the value to the uniform of the provided name.
+	SetUniform(name string, arg interface{}) error
+	// UniformBlockDescriptors returns the map of uniform block descriptors.
+	UniformBlockDescriptors() map[string]*UniformBlockDescriptor
+	// bound returns the GL bindings the program requires when in use.
+	bound() *boundProgram
+}
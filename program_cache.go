@@ -0,0 +1,162 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// ProgramCache stores linked program binaries on disk so that subsequent
+// runs can skip compilation and linking.
+type ProgramCache struct {
+	dir string
+}
+
+// NewProgramCache instantiates and returns a new program cache storing
+// binaries in the provided directory, creating it if necessary.
+func NewProgramCache(dir string) (*ProgramCache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &ProgramCache{
+		dir: dir,
+	}, nil
+}
+
+// NewShaderProgram instantiates a new shader object from the provided stages,
+// loading the linked binary from the cache if present. If the driver rejects
+// the cached binary the program is compiled and linked from source and the
+// cache entry is replaced.
+func (c *ProgramCache) NewShaderProgram(stages []*ShaderStage, options ...ProgramOption) (*Shader, error) {
+	return c.program(stages, nil, options)
+}
+
+// Clear removes all cached binaries.
+func (c *ProgramCache) Clear() error {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.bin"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ProgramCache) program(stages []*ShaderStage, defines *Defines, options []ProgramOption) (*Shader, error) {
+	shader := newShader(stages, options)
+	err := validateStages(stages, shader.separable)
+	if err != nil {
+		return nil, err
+	}
+	// fall back to compiling if the driver supports no binary formats
+	var numFormats int32
+	gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &numFormats)
+	if numFormats == 0 {
+		return NewShaderProgram(stages, options...)
+	}
+	// load sources so they can be hashed
+	loaded := make([]*ShaderStage, len(stages))
+	for i, stage := range stages {
+		copied := *stage
+		if !copied.loaded {
+			if !isGLSL(stage.Source) {
+				copied.Files = []string{stage.Source}
+			}
+			copied.Source, err = loadShaderSource(stage.Source)
+			if err != nil {
+				return nil, err
+			}
+			copied.loaded = true
+		}
+		loaded[i] = &copied
+	}
+	path := filepath.Join(c.dir, c.key(shader, loaded, defines)+".bin")
+	// attempt to load the cached binary
+	if shader.loadBinary(path) {
+		return shader, nil
+	}
+	// compile from source and cache the result
+	shader.retrievable = true
+	err = shader.build(loaded)
+	if err != nil {
+		return nil, err
+	}
+	// failing to write the cache only costs a recompile next time
+	shader.saveBinary(path)
+	return shader, nil
+}
+
+func (c *ProgramCache) key(shader *Shader, stages []*ShaderStage, defines *Defines) string {
+	hash := sha256.New()
+	write := func(str string) {
+		// length prefix each value so boundaries are unambiguous
+		binary.Write(hash, binary.LittleEndian, uint64(len(str)))
+		hash.Write([]byte(str))
+	}
+	write(gl.GoStr(gl.GetString(gl.VENDOR)))
+	write(gl.GoStr(gl.GetString(gl.RENDERER)))
+	write(gl.GoStr(gl.GetString(gl.VERSION)))
+	write(defines.Key())
+	write(shader.linkKey())
+	for _, stage := range stages {
+		write(fmt.Sprintf("%d", stage.Type))
+		write(stage.Source)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (s *Shader) loadBinary(path string) bool {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil || len(raw) <= 4 {
+		return false
+	}
+	format := binary.LittleEndian.Uint32(raw[:4])
+	data := raw[4:]
+	s.id = gl.CreateProgram()
+	if s.separable {
+		gl.ProgramParameteri(s.id, gl.PROGRAM_SEPARABLE, gl.TRUE)
+	}
+	gl.ProgramBinary(s.id, format, gl.Ptr(data), int32(len(data)))
+	var status int32
+	gl.GetProgramiv(s.id, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		// the driver rejected the binary, most likely due to an update
+		s.Destroy()
+		os.Remove(path)
+		return false
+	}
+	s.queryUniforms()
+	s.queryAttributes()
+	s.queryOutputs()
+	return true
+}
+
+func (s *Shader) saveBinary(path string) error {
+	var length int32
+	gl.GetProgramiv(s.id, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length == 0 {
+		return fmt.Errorf("program binary is empty")
+	}
+	data := make([]byte, 4+length)
+	var format uint32
+	gl.GetProgramBinary(s.id, length, nil, &format, gl.Ptr(data[4:]))
+	binary.LittleEndian.PutUint32(data[:4], format)
+	// write to a temporary file first so partial writes are never loaded
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,165 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+var stageBits = map[uint32]uint32{
+	gl.VERTEX_SHADER:          gl.VERTEX_SHADER_BIT,
+	gl.TESS_CONTROL_SHADER:    gl.TESS_CONTROL_SHADER_BIT,
+	gl.TESS_EVALUATION_SHADER: gl.TESS_EVALUATION_SHADER_BIT,
+	gl.GEOMETRY_SHADER:        gl.GEOMETRY_SHADER_BIT,
+	gl.FRAGMENT_SHADER:        gl.FRAGMENT_SHADER_BIT,
+}
+
+// ProgramPipeline represents a program pipeline object combining the stages
+// of multiple separable shaders.
+type ProgramPipeline struct {
+	id      uint32
+	shaders []*Shader
+	bits    []uint32
+	// program ids the stages were last bound to, to detect reloads
+	programs         []uint32
+	blockDescriptors map[string]*UniformBlockDescriptor
+}
+
+// NewProgramPipeline instantiates and returns a new program pipeline from
+// the provided separable shaders. Each stage may be provided by only one
+// shader, and a vertex stage must be present.
+func NewProgramPipeline(shaders ...*Shader) (*ProgramPipeline, error) {
+	present := make(map[uint32]bool)
+	bits := make([]uint32, len(shaders))
+	for i, shader := range shaders {
+		if !shader.separable {
+			return nil, fmt.Errorf("program pipeline requires separable " +
+				"shaders, link them with the Separable option")
+		}
+		for _, stage := range shader.stages {
+			if present[stage.Type] {
+				return nil, fmt.Errorf("%s stage provided by more than one "+
+					"shader", stageName(stage.Type))
+			}
+			present[stage.Type] = true
+			bits[i] |= stageBits[stage.Type]
+		}
+	}
+	if !present[gl.VERTEX_SHADER] {
+		return nil, fmt.Errorf("program pipeline requires a vertex stage")
+	}
+	p := &ProgramPipeline{
+		shaders:  shaders,
+		bits:     bits,
+		programs: make([]uint32, len(shaders)),
+	}
+	gl.GenProgramPipelines(1, &p.id)
+	p.refresh()
+	return p, nil
+}
+
+// refresh rebinds the pipeline stages and merges the block descriptors if any
+// of the shaders were relinked since they were last bound.
+func (p *ProgramPipeline) refresh() {
+	stale := p.blockDescriptors == nil
+	for i, shader := range p.shaders {
+		if p.programs[i] != shader.id {
+			gl.UseProgramStages(p.id, p.bits[i], shader.id)
+			p.programs[i] = shader.id
+			stale = true
+		}
+	}
+	if !stale {
+		return
+	}
+	p.blockDescriptors = make(map[string]*UniformBlockDescriptor)
+	for _, shader := range p.shaders {
+		for name, descriptor := range shader.blockDescriptors {
+			if _, ok := p.blockDescriptors[name]; !ok {
+				p.blockDescriptors[name] = descriptor
+			}
+		}
+	}
+}
+
+// Use activates the program pipeline. The StateCache does not observe the
+// change, so call its Invalidate method before drawing through it again.
+func (p *ProgramPipeline) Use() {
+	p.refresh()
+	// a program bound with glUseProgram takes precedence over the pipeline
+	gl.UseProgram(0)
+	gl.BindProgramPipeline(p.id)
+}
+
+func (p *ProgramPipeline) bound() *boundProgram {
+	// rebinding stages of relinked shaders does not require a rebind
+	p.refresh()
+	return &boundProgram{
+		pipeline: p.id,
+	}
+}
+
+// SetUniform buffers the value to the uniform of the provided name in each
+// shader of the pipeline that declares it.
+func (p *ProgramPipeline) SetUniform(name string, arg interface{}) error {
+	found := false
+	for _, shader := range p.shaders {
+		_, ok := shader.descriptor(name)
+		if !ok && !(isStructValue(arg) && shader.hasStructUniform(name)) {
+			continue
+		}
+		err := shader.SetUniform(name, arg)
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("uniform `%s` was not recognized", name)
+	}
+	return nil
+}
+
+// UniformBlockDescriptors returns the merged map of uniform block descriptors
+// of all shaders in the pipeline.
+func (p *ProgramPipeline) UniformBlockDescriptors() map[string]*UniformBlockDescriptor {
+	p.refresh()
+	return p.blockDescriptors
+}
+
+// Shaders returns the shaders of the pipeline.
+func (p *ProgramPipeline) Shaders() []*Shader {
+	return p.shaders
+}
+
+// Validate checks whether the pipeline can execute given the current GL
+// state, returning the validation log if not.
+func (p *ProgramPipeline) Validate() error {
+	p.refresh()
+	gl.ValidateProgramPipeline(p.id)
+	var status int32
+	gl.GetProgramPipelineiv(p.id, gl.VALIDATE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramPipelineiv(p.id, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramPipelineInfoLog(p.id, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to validate program pipeline: %s",
+			strings.TrimSpace(strings.TrimRight(log, "\x00")))
+	}
+	return nil
+}
+
+// ID returns the ID of the program pipeline.
+func (p *ProgramPipeline) ID() uint32 {
+	return p.id
+}
+
+// Destroy deallocates the program pipeline. The shaders are not destroyed.
+func (p *ProgramPipeline) Destroy() {
+	if p.id != 0 {
+		gl.DeleteProgramPipelines(1, &p.id)
+		p.id = 0
+	}
+}
@@ -0,0 +1,304 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// LoadAction describes what happens to the contents of an attachment at the
+// start of a render pass.
+type LoadAction int
+
+const (
+	// LoadActionLoad preserves the existing contents of the attachment.
+	LoadActionLoad LoadAction = iota
+	// LoadActionClear clears the attachment to its clear value.
+	LoadActionClear
+	// LoadActionDontCare leaves the contents of the attachment undefined,
+	// as they will be entirely overwritten.
+	LoadActionDontCare
+)
+
+// StoreAction describes what happens to the contents of an attachment at the
+// end of a render pass.
+type StoreAction int
+
+const (
+	// StoreActionStore preserves the rendered contents of the attachment.
+	StoreActionStore StoreAction = iota
+	// StoreActionDiscard discards the rendered contents of the attachment,
+	// such as a depth buffer that is not needed after the pass.
+	StoreActionDiscard
+)
+
+type passAttachment struct {
+	load  LoadAction
+	store StoreAction
+	color [4]float32
+}
+
+// RenderPass represents a sequence of techniques drawing into a single
+// target framebuffer, with actions describing how each attachment is loaded
+// at the start of the pass and stored at the end. Each call requires the
+// cache of the current context.
+type RenderPass struct {
+	framebuffer *FrameBuffer
+	attachments map[uint32]*passAttachment
+	depth       float32
+	stencil     int32
+	viewport    *Viewport
+	active      bool
+}
+
+// NewRenderPass instantiates and returns a new render pass drawing into the
+// provided framebuffer, or the default framebuffer if nil. All attachments
+// are loaded and stored unless specified otherwise.
+func NewRenderPass(framebuffer *FrameBuffer) *RenderPass {
+	return &RenderPass{
+		framebuffer: framebuffer,
+		attachments: make(map[uint32]*passAttachment),
+		depth:       1,
+	}
+}
+
+func (p *RenderPass) attachment(attachment uint32) *passAttachment {
+	a, ok := p.attachments[attachment]
+	if !ok {
+		a = &passAttachment{}
+		p.attachments[attachment] = a
+	}
+	return a
+}
+
+// Load sets the load action of the provided attachment, such as
+// gl.COLOR_ATTACHMENT0 or gl.DEPTH_ATTACHMENT.
+func (p *RenderPass) Load(attachment uint32, action LoadAction) {
+	p.attachment(attachment).load = action
+}
+
+// Store sets the store action of the provided attachment.
+func (p *RenderPass) Store(attachment uint32, action StoreAction) {
+	p.attachment(attachment).store = action
+}
+
+// ClearColor sets the color attachment to be cleared to the provided color
+// at the start of the pass.
+func (p *RenderPass) ClearColor(attachment uint32, r, g, b, a float32) {
+	att := p.attachment(attachment)
+	att.load = LoadActionClear
+	att.color = [4]float32{r, g, b, a}
+}
+
+// ClearDepth sets the depth attachment to be cleared to the provided depth
+// at the start of the pass.
+func (p *RenderPass) ClearDepth(depth float32) {
+	p.attachment(gl.DEPTH_ATTACHMENT).load = LoadActionClear
+	p.depth = depth
+}
+
+// ClearStencil sets the stencil attachment to be cleared to the provided
+// value at the start of the pass.
+func (p *RenderPass) ClearStencil(s int32) {
+	p.attachment(gl.STENCIL_ATTACHMENT).load = LoadActionClear
+	p.stencil = s
+}
+
+// Viewport sets the viewport for the pass. If unset, the viewport covers the
+// attachments of the target framebuffer. Techniques with a viewport of their
+// own override it.
+func (p *RenderPass) Viewport(viewport *Viewport) {
+	p.viewport = viewport
+}
+
+// Begin binds the target framebuffer and applies the load actions of its
+// attachments. Draw buffers set on the framebuffer, such as by
+// SetDrawBuffersForShader, are kept for the duration of the pass. Otherwise
+// color attachment i is bound to draw buffer i.
+func (p *RenderPass) Begin(cache *StateCache) error {
+	if cache == nil {
+		return fmt.Errorf("render pass requires a state cache")
+	}
+	if p.active {
+		return fmt.Errorf("render pass has already begun")
+	}
+	cache.bindFrameBuffer(p.framebuffer)
+	var mapping []uint32
+	if p.framebuffer != nil {
+		mapping = p.framebuffer.drawBuffers
+		// color clears address attachment i through draw buffer i
+		if mapping == nil || p.clearsColor() {
+			p.framebuffer.SetDrawBuffers(p.drawBuffers())
+		}
+	}
+	cache.setViewport(p.targetViewport())
+
+	// clears are limited by the scissor test and skipped while discarding
+	cache.disable(gl.SCISSOR_TEST)
+	cache.disable(gl.RASTERIZER_DISCARD)
+
+	var dontCare []uint32
+	for _, attachment := range p.sortedAttachments() {
+		a := p.attachments[attachment]
+		switch a.load {
+		case LoadActionClear:
+			p.clear(cache, attachment, a)
+		case LoadActionDontCare:
+			dontCare = append(dontCare, attachment)
+		}
+	}
+	p.invalidate(cache, dontCare)
+	if mapping != nil && p.clearsColor() {
+		// restore the mapping the framebuffer was configured with
+		p.framebuffer.SetDrawBuffers(mapping)
+	}
+	p.active = true
+	return nil
+}
+
+// Draw renders the commands using the technique within the pass.
+func (p *RenderPass) Draw(cache *StateCache, technique *Technique, commands []*Command) error {
+	if cache == nil {
+		return fmt.Errorf("render pass requires a state cache")
+	}
+	if !p.active {
+		return fmt.Errorf("render pass has not begun")
+	}
+	return technique.draw(cache, p.framebuffer, p.targetViewport(), commands)
+}
+
+// End applies the store actions of the attachments and ends the pass.
+func (p *RenderPass) End(cache *StateCache) error {
+	if cache == nil {
+		return fmt.Errorf("render pass requires a state cache")
+	}
+	if !p.active {
+		return fmt.Errorf("render pass has not begun")
+	}
+	var discard []uint32
+	for _, attachment := range p.sortedAttachments() {
+		if p.attachments[attachment].store == StoreActionDiscard {
+			discard = append(discard, attachment)
+		}
+	}
+	// the framebuffer may have been changed by foreign calls within the pass
+	cache.bindFrameBuffer(p.framebuffer)
+	p.invalidate(cache, discard)
+	p.active = false
+	return nil
+}
+
+func (p *RenderPass) clear(cache *StateCache, attachment uint32, a *passAttachment) {
+	switch attachment {
+	case gl.DEPTH_ATTACHMENT:
+		// depth is only cleared while depth writes are enabled
+		cache.setDepthMask(&depthMask{
+			flag: true,
+		})
+		gl.ClearBufferfv(gl.DEPTH, 0, &p.depth)
+	case gl.STENCIL_ATTACHMENT:
+		// stencil is only cleared where stencil writes are enabled
+		cache.enableStencilWrites()
+		gl.ClearBufferiv(gl.STENCIL, 0, &p.stencil)
+	case gl.DEPTH_STENCIL_ATTACHMENT:
+		cache.setDepthMask(&depthMask{
+			flag: true,
+		})
+		cache.enableStencilWrites()
+		gl.ClearBufferfi(gl.DEPTH_STENCIL, 0, p.depth, p.stencil)
+	default:
+		drawBuffer := int32(attachment - gl.COLOR_ATTACHMENT0)
+		if p.framebuffer == nil {
+			drawBuffer = 0
+		}
+		gl.ClearBufferfv(gl.COLOR, drawBuffer, &a.color[0])
+	}
+}
+
+// invalidate discards the contents of the provided attachments, if supported
+// by the context.
+func (p *RenderPass) invalidate(cache *StateCache, attachments []uint32) {
+	if len(attachments) == 0 || !cache.invalidateSupported() {
+		return
+	}
+	if p.framebuffer == nil {
+		// the default framebuffer names its buffers differently
+		for i, attachment := range attachments {
+			switch attachment {
+			case gl.DEPTH_ATTACHMENT:
+				attachments[i] = gl.DEPTH
+			case gl.STENCIL_ATTACHMENT:
+				attachments[i] = gl.STENCIL
+			case gl.DEPTH_STENCIL_ATTACHMENT:
+				attachments[i] = gl.DEPTH
+				attachments = append(attachments, gl.STENCIL)
+			default:
+				attachments[i] = gl.COLOR
+			}
+		}
+	}
+	gl.InvalidateFramebuffer(gl.FRAMEBUFFER, int32(len(attachments)), &attachments[0])
+}
+
+// clearsColor returns whether the pass clears any color attachment.
+func (p *RenderPass) clearsColor() bool {
+	for attachment, a := range p.attachments {
+		if a.load == LoadActionClear &&
+			attachment >= gl.COLOR_ATTACHMENT0 && attachment <= gl.COLOR_ATTACHMENT31 {
+			return true
+		}
+	}
+	return false
+}
+
+// drawBuffers maps each color attachment of the framebuffer to the draw
+// buffer of the same index.
+func (p *RenderPass) drawBuffers() []uint32 {
+	buffers := []uint32{gl.NONE}
+	for attachment := range p.framebuffer.textures {
+		if attachment < gl.COLOR_ATTACHMENT0 || attachment > gl.COLOR_ATTACHMENT31 {
+			continue
+		}
+		index := int(attachment - gl.COLOR_ATTACHMENT0)
+		for len(buffers) <= index {
+			buffers = append(buffers, gl.NONE)
+		}
+		buffers[index] = attachment
+	}
+	return buffers
+}
+
+// targetViewport returns the viewport of the pass, defaulting to the size of
+// the framebuffer attachments.
+func (p *RenderPass) targetViewport() *Viewport {
+	if p.viewport != nil || p.framebuffer == nil {
+		return p.viewport
+	}
+	attachments := make([]uint32, 0, len(p.framebuffer.textures))
+	for attachment := range p.framebuffer.textures {
+		attachments = append(attachments, attachment)
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i] < attachments[j]
+	})
+	texture := p.framebuffer.textures[attachments[0]]
+	return &Viewport{
+		Width:  int32(texture.Width()),
+		Height: int32(texture.Height()),
+	}
+}
+
+func (p *RenderPass) sortedAttachments() []uint32 {
+	attachments := make([]uint32, 0, len(p.attachments))
+	for attachment := range p.attachments {
+		attachments = append(attachments, attachment)
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i] < attachments[j]
+	})
+	return attachments
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestRenderPassDrawBuffers(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments []uint32
+		expected    []uint32
+	}{
+		{"none", nil, []uint32{gl.NONE}},
+		{"depth only", []uint32{gl.DEPTH_ATTACHMENT}, []uint32{gl.NONE}},
+		{
+			"sparse",
+			[]uint32{gl.COLOR_ATTACHMENT2, gl.COLOR_ATTACHMENT0, gl.DEPTH_ATTACHMENT},
+			[]uint32{gl.COLOR_ATTACHMENT0, gl.NONE, gl.COLOR_ATTACHMENT2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			framebuffer := &FrameBuffer{
+				textures: make(map[uint32]*Texture),
+			}
+			for _, attachment := range test.attachments {
+				framebuffer.textures[attachment] = &Texture{}
+			}
+			pass := NewRenderPass(framebuffer)
+			if buffers := pass.drawBuffers(); !reflect.DeepEqual(buffers, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, buffers)
+			}
+		})
+	}
+}
+
+func TestRenderPassClearsColor(t *testing.T) {
+	pass := NewRenderPass(nil)
+	pass.ClearDepth(1)
+	pass.Load(gl.COLOR_ATTACHMENT0, LoadActionDontCare)
+	if pass.clearsColor() {
+		t.Errorf("expected pass not to clear color")
+	}
+	pass.ClearColor(gl.COLOR_ATTACHMENT1, 0, 0, 0, 1)
+	if !pass.clearsColor() {
+		t.Errorf("expected pass to clear color")
+	}
+}
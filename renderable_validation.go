@@ -0,0 +1,194 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// AttributeMismatch represents an attribute pointer that does not match the
+// shader input at its index. Pointer is nil if the index has no pointer.
+type AttributeMismatch struct {
+	Attribute *AttributeDescriptor
+	Index     uint32
+	Pointer   *AttributePointer
+	Reason    string
+}
+
+// AttributeMismatchError represents the differences between the attribute
+// pointers of a renderable and the vertex inputs of a shader.
+type AttributeMismatchError struct {
+	// Missing are shader inputs with no attribute pointer.
+	Missing []*AttributeDescriptor
+	// Extra are attribute pointer indices no shader input reads.
+	Extra []uint32
+	// Mismatched are attribute pointers incompatible with their input, and
+	// locations of partially fed inputs with no pointer.
+	Mismatched []AttributeMismatch
+}
+
+func (e *AttributeMismatchError) Error() string {
+	var msgs []string
+	for _, attribute := range e.Missing {
+		msgs = append(msgs, fmt.Sprintf("attribute `%s` at location %d has no pointer",
+			attribute.Name, attribute.Location))
+	}
+	for _, index := range e.Extra {
+		msgs = append(msgs, fmt.Sprintf("pointer at index %d is not read by the shader",
+			index))
+	}
+	for _, mismatch := range e.Mismatched {
+		msgs = append(msgs, fmt.Sprintf("attribute `%s` at index %d: %s",
+			mismatch.Attribute.Name, mismatch.Index, mismatch.Reason))
+	}
+	return fmt.Sprintf("renderable does not match shader inputs: %s",
+		strings.Join(msgs, ", "))
+}
+
+// Validate checks the attribute pointers of the renderable against the
+// vertex inputs of the provided shader, returning an *AttributeMismatchError
+// describing any missing, extra or incompatible attributes.
+func (r *Renderable) Validate(shader *Shader) error {
+	mismatch := &AttributeMismatchError{}
+	covered := make(map[uint32]bool)
+
+	// sort attributes by location for deterministic output
+	attributes := make([]*AttributeDescriptor, 0, len(shader.attributes))
+	for _, attribute := range shader.attributes {
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].Location < attributes[j].Location
+	})
+
+	for _, attribute := range attributes {
+		base, components, locations := attributeLayout(attribute.Type)
+		// arrays and matrices span multiple consecutive locations
+		indices := make([]uint32, locations*attribute.Count)
+		fed := 0
+		for i := range indices {
+			indices[i] = uint32(attribute.Location) + uint32(i)
+			covered[indices[i]] = true
+			if _, ok := r.pointers[indices[i]]; ok {
+				fed++
+			}
+		}
+		if fed == 0 {
+			mismatch.Missing = append(mismatch.Missing, attribute)
+			continue
+		}
+		for _, index := range indices {
+			pointer, ok := r.pointers[index]
+			if !ok {
+				// a partially fed input reads garbage from its other locations
+				mismatch.Mismatched = append(mismatch.Mismatched, AttributeMismatch{
+					Attribute: attribute,
+					Index:     index,
+					Reason:    fmt.Sprintf("no pointer for location %d", index),
+				})
+				continue
+			}
+			reason := pointerMismatch(pointer, base, components)
+			if reason != "" {
+				mismatch.Mismatched = append(mismatch.Mismatched, AttributeMismatch{
+					Attribute: attribute,
+					Index:     index,
+					Pointer:   pointer,
+					Reason:    reason,
+				})
+			}
+		}
+	}
+
+	for index := range r.pointers {
+		if !covered[index] {
+			mismatch.Extra = append(mismatch.Extra, index)
+		}
+	}
+	sort.Slice(mismatch.Extra, func(i, j int) bool {
+		return mismatch.Extra[i] < mismatch.Extra[j]
+	})
+
+	if len(mismatch.Missing) == 0 &&
+		len(mismatch.Extra) == 0 &&
+		len(mismatch.Mismatched) == 0 {
+		return nil
+	}
+	return mismatch
+}
+
+func pointerMismatch(pointer *AttributePointer, base uint32, components int32) string {
+	// pointers are always specified with glVertexAttribPointer, which converts
+	// to floating point
+	switch base {
+	case gl.INT, gl.UNSIGNED_INT:
+		return "integer inputs cannot be sourced from a floating point pointer"
+	case gl.DOUBLE:
+		return "double inputs cannot be sourced from a floating point pointer"
+	}
+	if pointer.Size > components {
+		return fmt.Sprintf("pointer has %d components, input has %d",
+			pointer.Size, components)
+	}
+	return ""
+}
+
+// attributeLayout returns the component base type, the number of components
+// per location and the number of locations of an attribute type.
+func attributeLayout(typ uint32) (uint32, int32, int32) {
+	switch typ {
+	case gl.FLOAT:
+		return gl.FLOAT, 1, 1
+	case gl.FLOAT_VEC2:
+		return gl.FLOAT, 2, 1
+	case gl.FLOAT_VEC3:
+		return gl.FLOAT, 3, 1
+	case gl.FLOAT_VEC4:
+		return gl.FLOAT, 4, 1
+	case gl.FLOAT_MAT2:
+		return gl.FLOAT, 2, 2
+	case gl.FLOAT_MAT2x3:
+		return gl.FLOAT, 3, 2
+	case gl.FLOAT_MAT2x4:
+		return gl.FLOAT, 4, 2
+	case gl.FLOAT_MAT3:
+		return gl.FLOAT, 3, 3
+	case gl.FLOAT_MAT3x2:
+		return gl.FLOAT, 2, 3
+	case gl.FLOAT_MAT3x4:
+		return gl.FLOAT, 4, 3
+	case gl.FLOAT_MAT4:
+		return gl.FLOAT, 4, 4
+	case gl.FLOAT_MAT4x2:
+		return gl.FLOAT, 2, 4
+	case gl.FLOAT_MAT4x3:
+		return gl.FLOAT, 3, 4
+	case gl.INT:
+		return gl.INT, 1, 1
+	case gl.INT_VEC2:
+		return gl.INT, 2, 1
+	case gl.INT_VEC3:
+		return gl.INT, 3, 1
+	case gl.INT_VEC4:
+		return gl.INT, 4, 1
+	case gl.UNSIGNED_INT:
+		return gl.UNSIGNED_INT, 1, 1
+	case gl.UNSIGNED_INT_VEC2:
+		return gl.UNSIGNED_INT, 2, 1
+	case gl.UNSIGNED_INT_VEC3:
+		return gl.UNSIGNED_INT, 3, 1
+	case gl.UNSIGNED_INT_VEC4:
+		return gl.UNSIGNED_INT, 4, 1
+	case gl.DOUBLE:
+		return gl.DOUBLE, 1, 1
+	case gl.DOUBLE_VEC2:
+		return gl.DOUBLE, 2, 1
+	case gl.DOUBLE_VEC3:
+		return gl.DOUBLE, 3, 1
+	case gl.DOUBLE_VEC4:
+		return gl.DOUBLE, 4, 1
+	}
+	return gl.FLOAT, 4, 1
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestRenderableValidate(t *testing.T) {
+	pointer := func(index uint32, size int32) *AttributePointer {
+		return &AttributePointer{Index: index, Size: size, Type: gl.FLOAT}
+	}
+	shader := &Shader{
+		attributes: map[string]*AttributeDescriptor{
+			"position": {Name: "position", Type: gl.FLOAT_VEC3, Count: 1, Location: 0},
+			"model":    {Name: "model", Type: gl.FLOAT_MAT4, Count: 1, Location: 1},
+			"weights":  {Name: "weights", Type: gl.FLOAT, Count: 2, Location: 5},
+		},
+	}
+	tests := []struct {
+		name       string
+		pointers   []*AttributePointer
+		missing    []string
+		extra      []uint32
+		mismatched []uint32
+	}{
+		{
+			name: "matching",
+			pointers: []*AttributePointer{
+				pointer(0, 3),
+				pointer(1, 4), pointer(2, 4), pointer(3, 4), pointer(4, 4),
+				pointer(5, 1), pointer(6, 1),
+			},
+		},
+		{
+			name:     "missing",
+			pointers: []*AttributePointer{pointer(0, 3)},
+			missing:  []string{"model", "weights"},
+		},
+		{
+			name: "partially fed matrix",
+			pointers: []*AttributePointer{
+				pointer(0, 3),
+				pointer(1, 4),
+				pointer(5, 1), pointer(6, 1),
+			},
+			mismatched: []uint32{2, 3, 4},
+		},
+		{
+			name: "partially fed array",
+			pointers: []*AttributePointer{
+				pointer(0, 3),
+				pointer(1, 4), pointer(2, 4), pointer(3, 4), pointer(4, 4),
+				pointer(6, 1),
+			},
+			mismatched: []uint32{5},
+		},
+		{
+			name: "extra and oversized",
+			pointers: []*AttributePointer{
+				pointer(0, 4),
+				pointer(1, 4), pointer(2, 4), pointer(3, 4), pointer(4, 4),
+				pointer(5, 1), pointer(6, 1),
+				pointer(7, 2),
+			},
+			extra:      []uint32{7},
+			mismatched: []uint32{0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			renderable := &Renderable{
+				pointers: make(map[uint32]*AttributePointer),
+			}
+			for _, p := range test.pointers {
+				renderable.pointers[p.Index] = p
+			}
+			err := renderable.Validate(shader)
+			if len(test.missing) == 0 && len(test.extra) == 0 && len(test.mismatched) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			mismatch, ok := err.(*AttributeMismatchError)
+			if !ok {
+				t.Fatalf("expected *AttributeMismatchError, got %v", err)
+			}
+			var missing []string
+			for _, attribute := range mismatch.Missing {
+				missing = append(missing, attribute.Name)
+			}
+			var mismatched []uint32
+			for _, m := range mismatch.Mismatched {
+				mismatched = append(mismatched, m.Index)
+			}
+			if !reflect.DeepEqual(missing, test.missing) {
+				t.Errorf("expected missing %v, got %v", test.missing, missing)
+			}
+			if !reflect.DeepEqual(mismatch.Extra, test.extra) {
+				t.Errorf("expected extra %v, got %v", test.extra, mismatch.Extra)
+			}
+			if !reflect.DeepEqual(mismatched, test.mismatched) {
+				t.Errorf("expected mismatched %v, got %v", test.mismatched, mismatched)
+			}
+		})
+	}
+}
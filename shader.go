@@ -25,25 +25,67 @@ func isGLSL(str string) bool {
 type Shader struct {
 	id               uint32
 	shaders          []uint32
-	descriptors      map[string]*UniformDescriptor
-	blockDescriptors map[string]*UniformBlockDescriptor
+	stages           []*ShaderStage
+	retrievable      bool
+	separable        bool
+	feedbackMode     uint32
+	feedbackVaryings []string
+	// explicit locations bound before linking
+	attribLocations   map[string]uint32
+	fragDataLocations map[string]uint32
+	outputs           map[string]*FragmentOutputDescriptor
+	descriptors       map[string]*UniformDescriptor
+	blockDescriptors  map[string]*UniformBlockDescriptor
+	attributes        map[string]*AttributeDescriptor
+	blockBindings     map[string]uint32
+	elements          map[string]*UniformDescriptor
+	structs           map[structKey][]structField
+	structNames       map[string]bool
+	uniformCache      map[int32]interface{}
+	uniformAliases    map[int32]int32
+	uniformStats      UniformCacheStats
 }
 
-// Use activates the shader.
+// Use activates the shader. Techniques activate their program through the
+// StateCache, which must be invalidated after calling Use directly.
 func (s *Shader) Use() {
 	gl.UseProgram(s.id)
 }
 
-// CreateShader creates an individual shader object.
+func (s *Shader) bound() *boundProgram {
+	return &boundProgram{
+		program: s.id,
+	}
+}
+
+// CreateShader creates an individual shader object. The source is read from
+// a file unless it appears to contain a `main` function, use
+// NewShaderFromSource or NewShaderFromFS to load shaders explicitly.
 func (s *Shader) CreateShader(source string, typ uint32) (uint32, error) {
+	var files []string
 	if !isGLSL(source) {
-		// load shader file into memory
-		raw, err := ioutil.ReadFile(source)
-		if err != nil {
-			return 0, err
-		}
-		source = string(raw)
+		files = []string{source}
+	}
+	source, err := loadShaderSource(source)
+	if err != nil {
+		return 0, err
+	}
+	return s.compileShader(source, typ, files)
+}
+
+func loadShaderSource(source string) (string, error) {
+	if isGLSL(source) {
+		return source, nil
 	}
+	// load shader file into memory
+	raw, err := ioutil.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func (s *Shader) compileShader(source string, typ uint32, files []string) (uint32, error) {
 	// create shader object
 	shader := gl.CreateShader(typ)
 	// get c string
@@ -64,9 +106,20 @@ func (s *Shader) CreateShader(source string, typ uint32) (uint32, error) {
 		// get error message
 		log := strings.Repeat("\x00", int(logLength+1))
 		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		log = strings.TrimRight(log, "\x00")
 		// delete current objects and abort constructor
 		gl.DeleteShader(shader)
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+		var file string
+		if len(files) > 0 {
+			file = files[0]
+		}
+		return 0, &ShaderCompileError{
+			Stage:       typ,
+			File:        file,
+			Source:      source,
+			Log:         log,
+			Diagnostics: parseInfoLog(log, files),
+		}
 	}
 	// return shader object
 	return shader, nil
@@ -77,15 +130,37 @@ func (s *Shader) AttachShader(shader uint32) {
 	if s.id == 0 {
 		s.id = gl.CreateProgram()
 	}
-	if s.shaders == nil {
-		s.shaders = make([]uint32, 0)
-		s.shaders = append(s.shaders, shader)
-	}
+	s.shaders = append(s.shaders, shader)
 	gl.AttachShader(s.id, shader)
 }
 
 // LinkProgram links the shader program.
 func (s *Shader) LinkProgram() error {
+	// flag the binary as retrievable if it is to be cached
+	if s.retrievable {
+		gl.ProgramParameteri(s.id, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
+	// flag the program as separable if it is to be used in a pipeline
+	if s.separable {
+		gl.ProgramParameteri(s.id, gl.PROGRAM_SEPARABLE, gl.TRUE)
+	}
+	// declare transform feedback varyings
+	if len(s.feedbackVaryings) > 0 {
+		varyings := make([]string, len(s.feedbackVaryings))
+		for i, name := range s.feedbackVaryings {
+			varyings[i] = name + "\x00"
+		}
+		cstrs, free := gl.Strs(varyings...)
+		gl.TransformFeedbackVaryings(s.id, int32(len(varyings)), cstrs, s.feedbackMode)
+		free()
+	}
+	// bind explicit attribute and fragment output locations
+	for name, location := range s.attribLocations {
+		gl.BindAttribLocation(s.id, location, gl.Str(name+"\x00"))
+	}
+	for name, color := range s.fragDataLocations {
+		gl.BindFragDataLocation(s.id, color, gl.Str(name+"\x00"))
+	}
 	// link shader program
 	gl.LinkProgram(s.id)
 	// error check
@@ -96,150 +171,608 @@ func (s *Shader) LinkProgram() error {
 		gl.GetProgramiv(s.id, gl.INFO_LOG_LENGTH, &logLength)
 		log := strings.Repeat("\x00", int(logLength+1))
 		gl.GetProgramInfoLog(s.id, logLength, nil, gl.Str(log))
+		log = strings.TrimRight(log, "\x00")
 		// delete shader objects
 		s.deleteShaders()
-		return fmt.Errorf("failed to link program: %v", log)
+		return &ProgramLinkError{
+			Log:         log,
+			Diagnostics: parseInfoLog(log, nil),
+		}
 	}
 	// delete shader objects
 	s.deleteShaders()
-	// query uniform information
+	// query uniform, attribute and output information
 	s.queryUniforms()
+	s.queryAttributes()
+	s.queryOutputs()
 	return nil
 }
 
-// SetUniform1i buffers a int32 by value.
+// SetUniform1i buffers an int32 by value.
 func (s *Shader) SetUniform1i(location int32, arg interface{}) error {
-	value, ok := arg.(int32)
-	if !ok {
-		return fmt.Errorf("%v is not of type int32", arg)
+	values, _, err := int32Values(arg, 1, 1)
+	if err != nil {
+		return err
 	}
-	gl.Uniform1i(location, value)
+	if s.cachedInt32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1i(s.id, location, values[0])
 	return nil
 }
 
 // SetUniform1ui buffers an uint32 by value.
 func (s *Shader) SetUniform1ui(location int32, arg interface{}) error {
-	value, ok := arg.(uint32)
-	if !ok {
-		return fmt.Errorf("%v is not of type uint32", arg)
+	values, _, err := uint32Values(arg, 1, 1)
+	if err != nil {
+		return err
 	}
-	gl.Uniform1ui(location, value)
+	if s.cachedUint32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1ui(s.id, location, values[0])
 	return nil
 }
 
 // SetUniform1f buffers a float32 by value.
 func (s *Shader) SetUniform1f(location int32, arg interface{}) error {
-	value, ok := arg.(float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type float32", arg)
+	values, _, err := float32Values(arg, 1, 1)
+	if err != nil {
+		return err
 	}
-	gl.Uniform1f(location, value)
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1f(s.id, location, values[0])
 	return nil
 }
 
-// SetUniform1iv buffers one or more int32 by address.
+// SetUniform1iv buffers one or more int32 from an array or slice.
 func (s *Shader) SetUniform1iv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*int32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *int32", arg)
+	values, n, err := int32Values(arg, 1, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedInt32(location, values) {
+		return nil
 	}
-	gl.Uniform1iv(location, count, value)
+	gl.ProgramUniform1iv(s.id, location, n, &values[0])
 	return nil
 }
 
-// SetUniform1uiv buffers  one or more uint32 by address.
+// SetUniform1uiv buffers one or more uint32 from an array or slice.
 func (s *Shader) SetUniform1uiv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*uint32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *uint32", arg)
+	values, n, err := uint32Values(arg, 1, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedUint32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1uiv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform2iv buffers one or more 2-component int32 from an array or slice.
+func (s *Shader) SetUniform2iv(location int32, count int32, arg interface{}) error {
+	values, n, err := int32Values(arg, 2, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedInt32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform2iv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform3iv buffers one or more 3-component int32 from an array or slice.
+func (s *Shader) SetUniform3iv(location int32, count int32, arg interface{}) error {
+	values, n, err := int32Values(arg, 3, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedInt32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform3iv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform4iv buffers one or more 4-component int32 from an array or slice.
+func (s *Shader) SetUniform4iv(location int32, count int32, arg interface{}) error {
+	values, n, err := int32Values(arg, 4, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedInt32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform4iv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform2uiv buffers one or more 2-component uint32 from an array or slice.
+func (s *Shader) SetUniform2uiv(location int32, count int32, arg interface{}) error {
+	values, n, err := uint32Values(arg, 2, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedUint32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform2uiv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform3uiv buffers one or more 3-component uint32 from an array or slice.
+func (s *Shader) SetUniform3uiv(location int32, count int32, arg interface{}) error {
+	values, n, err := uint32Values(arg, 3, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedUint32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform3uiv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform4uiv buffers one or more 4-component uint32 from an array or slice.
+func (s *Shader) SetUniform4uiv(location int32, count int32, arg interface{}) error {
+	values, n, err := uint32Values(arg, 4, count)
+	if err != nil {
+		return err
 	}
-	gl.Uniform1uiv(location, count, value)
+	if s.cachedUint32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform4uiv(s.id, location, n, &values[0])
 	return nil
 }
 
-// SetUniform1fv buffers one or more float32 by address.
+// SetUniform1fv buffers one or more float32 from an array or slice.
 func (s *Shader) SetUniform1fv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *float32", arg)
+	values, n, err := float32Values(arg, 1, count)
+	if err != nil {
+		return err
 	}
-	gl.Uniform1fv(location, count, value)
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1fv(s.id, location, n, &values[0])
 	return nil
 }
 
-// SetUniform2fv buffers one or more 2-component float32 by address.
+// SetUniform2fv buffers one or more 2-component float32 from an array or slice.
 func (s *Shader) SetUniform2fv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *float32", arg)
+	values, n, err := float32Values(arg, 2, count)
+	if err != nil {
+		return err
 	}
-	gl.Uniform2fv(location, count, value)
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform2fv(s.id, location, n, &values[0])
 	return nil
 }
 
-// SetUniform3fv buffers one or more 3-component float32 by address.
+// SetUniform3fv buffers one or more 3-component float32 from an array or slice.
 func (s *Shader) SetUniform3fv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *float32", arg)
+	values, n, err := float32Values(arg, 3, count)
+	if err != nil {
+		return err
 	}
-	gl.Uniform3fv(location, count, value)
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform3fv(s.id, location, n, &values[0])
 	return nil
 }
 
-// SetUniform4fv buffers one or more 4-component float32 by address.
+// SetUniform4fv buffers one or more 4-component float32 from an array or slice.
 func (s *Shader) SetUniform4fv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *float32", arg)
+	values, n, err := float32Values(arg, 4, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniform4fv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform1d buffers a float64 by value.
+func (s *Shader) SetUniform1d(location int32, arg interface{}) error {
+	values, _, err := float64Values(arg, 1, 1)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1d(s.id, location, values[0])
+	return nil
+}
+
+// SetUniform1dv buffers one or more float64 from an array or slice.
+func (s *Shader) SetUniform1dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 1, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniform1dv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform2dv buffers one or more 2-component float64 from an array or slice.
+func (s *Shader) SetUniform2dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 2, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniform2dv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform3dv buffers one or more 3-component float64 from an array or slice.
+func (s *Shader) SetUniform3dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 3, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniform3dv(s.id, location, n, &values[0])
+	return nil
+}
+
+// SetUniform4dv buffers one or more 4-component float64 from an array or slice.
+func (s *Shader) SetUniform4dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 4, count)
+	if err != nil {
+		return err
 	}
-	gl.Uniform4fv(location, count, value)
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniform4dv(s.id, location, n, &values[0])
 	return nil
 }
 
-// SetUniformMatrix3fv buffers one or more 9-component float32 by address.
+// SetUniformMatrix2fv buffers one or more 4-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix2fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 4, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix2fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix3fv buffers one or more 9-component float32 from an array or slice.
 func (s *Shader) SetUniformMatrix3fv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *float32", arg)
+	values, n, err := float32Values(arg, 9, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
 	}
-	gl.UniformMatrix3fv(location, count, false, value)
+	gl.ProgramUniformMatrix3fv(s.id, location, n, false, &values[0])
 	return nil
 }
 
-// SetUniformMatrix4fv buffers one or more 16-component float32 by address.
+// SetUniformMatrix4fv buffers one or more 16-component float32 from an array or slice.
 func (s *Shader) SetUniformMatrix4fv(location int32, count int32, arg interface{}) error {
-	value, ok := arg.(*float32)
-	if !ok {
-		return fmt.Errorf("%v is not of type *float32", arg)
+	values, n, err := float32Values(arg, 16, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix4fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix2x3fv buffers one or more 6-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix2x3fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 6, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix2x3fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix2x4fv buffers one or more 8-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix2x4fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 8, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix2x4fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix3x2fv buffers one or more 6-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix3x2fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 6, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix3x2fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix3x4fv buffers one or more 12-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix3x4fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 12, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix3x4fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix4x2fv buffers one or more 8-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix4x2fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 8, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix4x2fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix4x3fv buffers one or more 12-component float32 from an array or slice.
+func (s *Shader) SetUniformMatrix4x3fv(location int32, count int32, arg interface{}) error {
+	values, n, err := float32Values(arg, 12, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat32(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix4x3fv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix2dv buffers one or more 4-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix2dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 4, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix2dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix2x3dv buffers one or more 6-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix2x3dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 6, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix2x3dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix2x4dv buffers one or more 8-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix2x4dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 8, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix2x4dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix3dv buffers one or more 9-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix3dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 9, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix3dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix3x2dv buffers one or more 6-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix3x2dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 6, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix3x2dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix3x4dv buffers one or more 12-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix3x4dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 12, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix3x4dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix4dv buffers one or more 16-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix4dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 16, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix4dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix4x2dv buffers one or more 8-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix4x2dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 8, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
+	}
+	gl.ProgramUniformMatrix4x2dv(s.id, location, n, false, &values[0])
+	return nil
+}
+
+// SetUniformMatrix4x3dv buffers one or more 12-component float64 from an array or slice.
+func (s *Shader) SetUniformMatrix4x3dv(location int32, count int32, arg interface{}) error {
+	values, n, err := float64Values(arg, 12, count)
+	if err != nil {
+		return err
+	}
+	if s.cachedFloat64(location, values) {
+		return nil
 	}
-	gl.UniformMatrix4fv(location, count, false, value)
+	gl.ProgramUniformMatrix4x3dv(s.id, location, n, false, &values[0])
 	return nil
 }
 
-// SetUniform buffers one or more uniforms.
+// SetUniform buffers one or more uniforms. Values may be provided as scalars,
+// or as arrays or slices of any length matching the uniform's type and count.
+// Arrays may be addressed by their base name or by individual element, and
+// structs, or arrays and slices of structs, are bound with SetUniformStruct.
 func (s *Shader) SetUniform(name string, arg interface{}) error {
 	// check descriptors
-	descriptor, ok := s.descriptors[name]
+	descriptor, ok := s.descriptor(name)
 	if !ok {
+		if isStructValue(arg) {
+			return s.SetUniformStruct(name, arg)
+		}
 		return fmt.Errorf("uniform `%s` was not recognized", name)
 	}
+	err := s.setUniform(descriptor, arg)
+	if err != nil {
+		return fmt.Errorf("failed to set uniform `%s`: %v", name, err)
+	}
+	return nil
+}
+
+func (s *Shader) setUniform(descriptor *UniformDescriptor, arg interface{}) error {
 	// buffer uniform data
 	switch descriptor.Type {
-	case gl.SAMPLER_2D:
-		return s.SetUniform1i(descriptor.Location, arg)
-	case gl.SAMPLER_CUBE:
+	// samplers are set by texture unit
+	case gl.SAMPLER_1D,
+		gl.SAMPLER_2D,
+		gl.SAMPLER_3D,
+		gl.SAMPLER_CUBE,
+		gl.SAMPLER_1D_SHADOW,
+		gl.SAMPLER_2D_SHADOW,
+		gl.SAMPLER_1D_ARRAY,
+		gl.SAMPLER_2D_ARRAY,
+		gl.SAMPLER_1D_ARRAY_SHADOW,
+		gl.SAMPLER_2D_ARRAY_SHADOW,
+		gl.SAMPLER_2D_MULTISAMPLE,
+		gl.SAMPLER_2D_MULTISAMPLE_ARRAY,
+		gl.SAMPLER_CUBE_SHADOW,
+		gl.SAMPLER_BUFFER,
+		gl.SAMPLER_2D_RECT,
+		gl.SAMPLER_2D_RECT_SHADOW,
+		gl.SAMPLER_CUBE_MAP_ARRAY,
+		gl.SAMPLER_CUBE_MAP_ARRAY_SHADOW,
+		gl.INT_SAMPLER_1D,
+		gl.INT_SAMPLER_2D,
+		gl.INT_SAMPLER_3D,
+		gl.INT_SAMPLER_CUBE,
+		gl.INT_SAMPLER_1D_ARRAY,
+		gl.INT_SAMPLER_2D_ARRAY,
+		gl.INT_SAMPLER_2D_MULTISAMPLE,
+		gl.INT_SAMPLER_2D_MULTISAMPLE_ARRAY,
+		gl.INT_SAMPLER_BUFFER,
+		gl.INT_SAMPLER_2D_RECT,
+		gl.INT_SAMPLER_CUBE_MAP_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_1D,
+		gl.UNSIGNED_INT_SAMPLER_2D,
+		gl.UNSIGNED_INT_SAMPLER_3D,
+		gl.UNSIGNED_INT_SAMPLER_CUBE,
+		gl.UNSIGNED_INT_SAMPLER_1D_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_2D_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE,
+		gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_BUFFER,
+		gl.UNSIGNED_INT_SAMPLER_2D_RECT,
+		gl.UNSIGNED_INT_SAMPLER_CUBE_MAP_ARRAY:
+		if descriptor.Count > 1 {
+			return s.SetUniform1iv(descriptor.Location, descriptor.Count, arg)
+		}
 		return s.SetUniform1i(descriptor.Location, arg)
-	case gl.INT:
+	// booleans may be set as integers
+	case gl.INT, gl.BOOL:
 		if descriptor.Count > 1 {
 			return s.SetUniform1iv(descriptor.Location, descriptor.Count, arg)
 		}
 		return s.SetUniform1i(descriptor.Location, arg)
+	case gl.INT_VEC2, gl.BOOL_VEC2:
+		return s.SetUniform2iv(descriptor.Location, descriptor.Count, arg)
+	case gl.INT_VEC3, gl.BOOL_VEC3:
+		return s.SetUniform3iv(descriptor.Location, descriptor.Count, arg)
+	case gl.INT_VEC4, gl.BOOL_VEC4:
+		return s.SetUniform4iv(descriptor.Location, descriptor.Count, arg)
 	case gl.UNSIGNED_INT:
 		if descriptor.Count > 1 {
 			return s.SetUniform1uiv(descriptor.Location, descriptor.Count, arg)
 		}
 		return s.SetUniform1ui(descriptor.Location, arg)
+	case gl.UNSIGNED_INT_VEC2:
+		return s.SetUniform2uiv(descriptor.Location, descriptor.Count, arg)
+	case gl.UNSIGNED_INT_VEC3:
+		return s.SetUniform3uiv(descriptor.Location, descriptor.Count, arg)
+	case gl.UNSIGNED_INT_VEC4:
+		return s.SetUniform4uiv(descriptor.Location, descriptor.Count, arg)
 	case gl.FLOAT:
 		if descriptor.Count > 1 {
 			return s.SetUniform1fv(descriptor.Location, descriptor.Count, arg)
@@ -251,11 +784,76 @@ func (s *Shader) SetUniform(name string, arg interface{}) error {
 		return s.SetUniform3fv(descriptor.Location, descriptor.Count, arg)
 	case gl.FLOAT_VEC4:
 		return s.SetUniform4fv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE:
+		if descriptor.Count > 1 {
+			return s.SetUniform1dv(descriptor.Location, descriptor.Count, arg)
+		}
+		return s.SetUniform1d(descriptor.Location, arg)
+	case gl.DOUBLE_VEC2:
+		return s.SetUniform2dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_VEC3:
+		return s.SetUniform3dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_VEC4:
+		return s.SetUniform4dv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT2:
+		return s.SetUniformMatrix2fv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT2x3:
+		return s.SetUniformMatrix2x3fv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT2x4:
+		return s.SetUniformMatrix2x4fv(descriptor.Location, descriptor.Count, arg)
 	case gl.FLOAT_MAT3:
 		return s.SetUniformMatrix3fv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT3x2:
+		return s.SetUniformMatrix3x2fv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT3x4:
+		return s.SetUniformMatrix3x4fv(descriptor.Location, descriptor.Count, arg)
 	case gl.FLOAT_MAT4:
 		return s.SetUniformMatrix4fv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT4x2:
+		return s.SetUniformMatrix4x2fv(descriptor.Location, descriptor.Count, arg)
+	case gl.FLOAT_MAT4x3:
+		return s.SetUniformMatrix4x3fv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT2:
+		return s.SetUniformMatrix2dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT2x3:
+		return s.SetUniformMatrix2x3dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT2x4:
+		return s.SetUniformMatrix2x4dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT3:
+		return s.SetUniformMatrix3dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT3x2:
+		return s.SetUniformMatrix3x2dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT3x4:
+		return s.SetUniformMatrix3x4dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT4:
+		return s.SetUniformMatrix4dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT4x2:
+		return s.SetUniformMatrix4x2dv(descriptor.Location, descriptor.Count, arg)
+	case gl.DOUBLE_MAT4x3:
+		return s.SetUniformMatrix4x3dv(descriptor.Location, descriptor.Count, arg)
 	}
+	return fmt.Errorf("unsupported type `0x%x`", descriptor.Type)
+}
+
+// SetUniformBlockBinding overrides the binding point of the named uniform
+// block for this shader only. The override persists across reloads. The
+// binding point is reserved for blocks of the name, and an error is returned
+// if it is already assigned to a block of a different name.
+func (s *Shader) SetUniformBlockBinding(name string, binding uint32) error {
+	descriptor, ok := s.blockDescriptors[name]
+	if !ok {
+		return fmt.Errorf("uniform block `%s` was not recognized", name)
+	}
+	err := reserveUniformBlockBinding(name, binding)
+	if err != nil {
+		return err
+	}
+	if s.blockBindings == nil {
+		s.blockBindings = make(map[string]uint32)
+	}
+	s.blockBindings[name] = binding
+	descriptor.Binding = binding
+	gl.UniformBlockBinding(s.id, descriptor.Index, binding)
 	return nil
 }
 
@@ -284,11 +882,20 @@ func (s *Shader) queryUniforms() {
 	uniformCounts := s.queryUniformCounts(uniformIndices)
 	parentBlockIndices := s.queryParentBlockIndices(uniformIndices)
 	uniformOffsets := s.queryUniformOffsets(uniformIndices)
+	arrayStrides := s.queryUniformArrayStrides(uniformIndices)
+	matrixStrides := s.queryUniformMatrixStrides(uniformIndices)
+	rowMajors := s.queryUniformRowMajors(uniformIndices)
 	uniformLocations := s.queryUniformLocations(uniformNames)
 
 	// create descriptor maps
 	s.descriptors = make(map[string]*UniformDescriptor)
 	s.blockDescriptors = make(map[string]*UniformBlockDescriptor)
+	// clear lazily resolved lookups
+	s.elements = nil
+	s.structs = nil
+	s.structNames = nil
+	s.uniformCache = nil
+	s.uniformAliases = nil
 
 	// for each uniform index
 	for _, index := range uniformIndices {
@@ -321,29 +928,78 @@ func (s *Shader) queryUniforms() {
 
 		// get all uniform offsets that are part of this block
 		offsets := make(map[string]int32)
+		members := make(map[string]*UniformBlockMember)
 		for i, parentIndex := range parentBlockIndices {
 			if parentIndex == int32(index) {
 				// uniform is part of this block
 				offsets[uniformNames[i]] = uniformOffsets[i]
+				members[uniformNames[i]] = &UniformBlockMember{
+					Name:         uniformNames[i],
+					Type:         uniformTypes[i],
+					Count:        uniformCounts[i],
+					Offset:       uniformOffsets[i],
+					ArrayStride:  arrayStrides[i],
+					MatrixStride: matrixStrides[i],
+					RowMajor:     rowMajors[i] != 0,
+				}
 			}
 		}
+		orderMembers(members)
 
 		blockName := blockNames[index]
 		blockIndex := blockIndices[index]
 		blockSize := blockSizes[index]
 
+		// use the shader override if present, otherwise the global binding
+		binding, ok := s.blockBindings[blockName]
+		if !ok {
+			binding = UniformBlockBinding(blockName)
+		}
+
 		// add block descriptor
 		s.blockDescriptors[blockName] = &UniformBlockDescriptor{
 			Name:      blockName,
 			Index:     blockIndex,
+			Binding:   binding,
 			Size:      blockSize,
 			Offsets:   offsets,
 			Alignment: bufferAlignment,
+			Members:   members,
+			Stages:    s.queryUniformBlockStages(blockIndex),
 		}
 
 		// set binding point for block index and shader
-		// TODO: make this configurable
-		gl.UniformBlockBinding(s.id, blockIndex, blockIndex)
+		gl.UniformBlockBinding(s.id, blockIndex, binding)
+	}
+}
+
+func (s *Shader) queryAttributes() {
+	// get the number of active attributes
+	var numActiveAttributes int32
+	gl.GetProgramiv(s.id, gl.ACTIVE_ATTRIBUTES, &numActiveAttributes)
+	var maxNameLength int32
+	gl.GetProgramiv(s.id, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxNameLength)
+
+	s.attributes = make(map[string]*AttributeDescriptor)
+
+	// for each attribute index
+	for i := int32(0); i < numActiveAttributes; i++ {
+		var length, count int32
+		var typ uint32
+		name := make([]uint8, maxNameLength+1)
+		gl.GetActiveAttrib(s.id, uint32(i), maxNameLength+1, &length, &count, &typ, &name[0])
+		attribName := string(name[:length])
+		location := gl.GetAttribLocation(s.id, gl.Str(attribName+"\x00"))
+		if location == -1 {
+			// built-in inputs such as gl_VertexID have no location
+			continue
+		}
+		s.attributes[attribName] = &AttributeDescriptor{
+			Name:     attribName,
+			Type:     typ,
+			Count:    count,
+			Location: location,
+		}
 	}
 }
 
@@ -357,6 +1013,16 @@ func (s *Shader) UniformBlockDescriptors() map[string]*UniformBlockDescriptor {
 	return s.blockDescriptors
 }
 
+// AttributeDescriptors returns the map of vertex attribute descriptors.
+func (s *Shader) AttributeDescriptors() map[string]*AttributeDescriptor {
+	return s.attributes
+}
+
+// FragmentOutputs returns the map of active fragment output descriptors.
+func (s *Shader) FragmentOutputs() map[string]*FragmentOutputDescriptor {
+	return s.outputs
+}
+
 func toString(buff []uint8) string {
 	b := make([]byte, len(buff))
 	for i, v := range buff {
@@ -453,6 +1119,39 @@ func (s *Shader) queryUniformOffsets(indices []uint32) []int32 {
 	return offsets
 }
 
+func (s *Shader) queryUniformArrayStrides(indices []uint32) []int32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]int32, 0)
+	}
+	// get uniform array strides (0 is not an array)
+	strides := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_ARRAY_STRIDE, &strides[0])
+	return strides
+}
+
+func (s *Shader) queryUniformMatrixStrides(indices []uint32) []int32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]int32, 0)
+	}
+	// get uniform matrix strides (0 is not a matrix)
+	strides := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_MATRIX_STRIDE, &strides[0])
+	return strides
+}
+
+func (s *Shader) queryUniformRowMajors(indices []uint32) []int32 {
+	// check if no uniforms
+	if len(indices) == 0 {
+		return make([]int32, 0)
+	}
+	// get whether uniform matrices are row major
+	rowMajors := make([]int32, len(indices))
+	gl.GetActiveUniformsiv(s.id, int32(len(indices)), &indices[0], gl.UNIFORM_IS_ROW_MAJOR, &rowMajors[0])
+	return rowMajors
+}
+
 func (s *Shader) queryUniformLocations(names []string) []int32 {
 	locations := make([]int32, len(names))
 	for i, name := range names {
@@ -499,6 +1198,29 @@ func (s *Shader) queryUniformBlockSizes(indices []uint32) []int32 {
 	return sizes
 }
 
+var blockReferences = []struct {
+	stage uint32
+	pname uint32
+}{
+	{gl.VERTEX_SHADER, gl.UNIFORM_BLOCK_REFERENCED_BY_VERTEX_SHADER},
+	{gl.TESS_CONTROL_SHADER, gl.UNIFORM_BLOCK_REFERENCED_BY_TESS_CONTROL_SHADER},
+	{gl.TESS_EVALUATION_SHADER, gl.UNIFORM_BLOCK_REFERENCED_BY_TESS_EVALUATION_SHADER},
+	{gl.GEOMETRY_SHADER, gl.UNIFORM_BLOCK_REFERENCED_BY_GEOMETRY_SHADER},
+	{gl.FRAGMENT_SHADER, gl.UNIFORM_BLOCK_REFERENCED_BY_FRAGMENT_SHADER},
+}
+
+func (s *Shader) queryUniformBlockStages(index uint32) []uint32 {
+	var stages []uint32
+	for _, ref := range blockReferences {
+		var referenced int32
+		gl.GetActiveUniformBlockiv(s.id, index, ref.pname, &referenced)
+		if referenced != gl.FALSE {
+			stages = append(stages, ref.stage)
+		}
+	}
+	return stages
+}
+
 func (s *Shader) queryUniformBufferAlignment() int32 {
 	var uniformBufferAlignment int32
 	gl.GetIntegerv(gl.UNIFORM_BUFFER_OFFSET_ALIGNMENT, &uniformBufferAlignment)
@@ -0,0 +1,221 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity represents the severity of a shader diagnostic.
+type Severity string
+
+const (
+	// SeverityError is the severity of a diagnostic that fails compilation.
+	SeverityError Severity = "error"
+	// SeverityWarning is the severity of a diagnostic that does not fail
+	// compilation.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo is the severity of an informational diagnostic.
+	SeverityInfo Severity = "info"
+)
+
+// Diagnostic represents a single message parsed from a shader or program
+// info log.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+	// source is the source string number reported by the driver
+	source int
+}
+
+// String returns the diagnostic in `file:line:column: severity: message`
+// form.
+func (d Diagnostic) String() string {
+	location := d.File
+	if location == "" {
+		location = strconv.Itoa(d.source)
+	}
+	if d.Line > 0 {
+		location += fmt.Sprintf(":%d", d.Line)
+		if d.Column > 0 {
+			location += fmt.Sprintf(":%d", d.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// ShaderCompileError represents a failure to compile a single shader stage.
+type ShaderCompileError struct {
+	// Stage is the shader type, such as gl.VERTEX_SHADER.
+	Stage uint32
+	// File is the file the stage was loaded from, if any.
+	File string
+	// Source is the source that failed to compile.
+	Source string
+	// Log is the raw info log reported by the driver.
+	Log string
+	// Diagnostics are the messages parsed from the info log.
+	Diagnostics []Diagnostic
+}
+
+func (e *ShaderCompileError) Error() string {
+	name := stageName(e.Stage) + " shader"
+	if e.File != "" {
+		name += fmt.Sprintf(" `%s`", e.File)
+	}
+	return fmt.Sprintf("failed to compile %s: %s", name, summarize(e.Log, e.Diagnostics))
+}
+
+// FormatDiagnostics returns each diagnostic followed by the provided number of
+// source lines surrounding the line it refers to.
+func (e *ShaderCompileError) FormatDiagnostics(context int) string {
+	lines := sourceLines(e.Source)
+	var b strings.Builder
+	for _, d := range e.Diagnostics {
+		b.WriteString(d.String())
+		b.WriteString("\n")
+		file, ok := lines[d.source]
+		if !ok || d.Line <= 0 {
+			continue
+		}
+		for n := d.Line - context; n <= d.Line+context; n++ {
+			text, ok := file[n]
+			if !ok {
+				continue
+			}
+			marker := " "
+			if n == d.Line {
+				marker = ">"
+			}
+			b.WriteString(fmt.Sprintf("%s %5d | %s\n", marker, n, text))
+			if n == d.Line && d.Column > 0 {
+				b.WriteString(fmt.Sprintf("  %5s | %s^\n", "",
+					strings.Repeat(" ", d.Column-1)))
+			}
+		}
+	}
+	return b.String()
+}
+
+// ProgramLinkError represents a failure to link a shader program.
+type ProgramLinkError struct {
+	// Log is the raw info log reported by the driver.
+	Log string
+	// Diagnostics are the messages parsed from the info log.
+	Diagnostics []Diagnostic
+}
+
+func (e *ProgramLinkError) Error() string {
+	return fmt.Sprintf("failed to link program: %s", summarize(e.Log, e.Diagnostics))
+}
+
+func summarize(log string, diagnostics []Diagnostic) string {
+	if len(diagnostics) == 0 {
+		return strings.TrimSpace(log)
+	}
+	messages := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		messages[i] = d.String()
+	}
+	return strings.Join(messages, "\n")
+}
+
+var (
+	// Mesa: `0:12(5): error: message`
+	mesaRegex = regexp.MustCompile(`^\s*(\d+):(\d+)\((\d+)\)\s*:\s*(?i:(error|warning|info))\s*:\s*(.*)$`)
+	// NVIDIA: `0(12) : error C1008: message`
+	nvidiaRegex = regexp.MustCompile(`^\s*(\d+)\((\d+)\)\s*:\s*(?i:(?:fatal\s+)?(error|warning|info))\s*(?:[A-Z]\d+)?\s*:\s*(.*)$`)
+	// AMD, Intel and ANGLE: `ERROR: 0:12: message`
+	amdRegex = regexp.MustCompile(`^\s*(?i:(error|warning|info))\s*:\s*(\d+):(\d+)\s*:\s*(.*)$`)
+)
+
+// parseInfoLog parses the diagnostics of a shader or program info log,
+// resolving source string numbers to the provided file names.
+func parseInfoLog(log string, files []string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(log, "\n") {
+		d, ok := parseDiagnostic(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+		if d.source >= 0 && d.source < len(files) {
+			d.File = files[d.source]
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+func parseDiagnostic(line string) (Diagnostic, bool) {
+	if m := mesaRegex.FindStringSubmatch(line); m != nil {
+		return Diagnostic{
+			source:   atoi(m[1]),
+			Line:     atoi(m[2]),
+			Column:   atoi(m[3]),
+			Severity: parseSeverity(m[4]),
+			Message:  m[5],
+		}, true
+	}
+	if m := nvidiaRegex.FindStringSubmatch(line); m != nil {
+		return Diagnostic{
+			source:   atoi(m[1]),
+			Line:     atoi(m[2]),
+			Severity: parseSeverity(m[3]),
+			Message:  m[4],
+		}, true
+	}
+	if m := amdRegex.FindStringSubmatch(line); m != nil {
+		return Diagnostic{
+			source:   atoi(m[2]),
+			Line:     atoi(m[3]),
+			Severity: parseSeverity(m[1]),
+			Message:  m[4],
+		}, true
+	}
+	return Diagnostic{}, false
+}
+
+func parseSeverity(str string) Severity {
+	switch strings.ToLower(str) {
+	case "warning":
+		return SeverityWarning
+	case "info":
+		return SeverityInfo
+	}
+	return SeverityError
+}
+
+func atoi(str string) int {
+	val, _ := strconv.Atoi(str)
+	return val
+}
+
+// sourceLines splits source into lines keyed by source string number and
+// line number, following any `#line` directives.
+func sourceLines(source string) map[int]map[int]string {
+	lines := make(map[int]map[int]string)
+	current, num := 0, 1
+	for _, line := range strings.Split(source, "\n") {
+		directive, arg := parseDirective(line)
+		if directive == "line" {
+			fields := strings.Fields(arg)
+			if len(fields) > 0 {
+				num = atoi(fields[0])
+			}
+			if len(fields) > 1 {
+				current = atoi(fields[1])
+			}
+			continue
+		}
+		if lines[current] == nil {
+			lines[current] = make(map[int]string)
+		}
+		lines[current][num] = line
+		num++
+	}
+	return lines
+}
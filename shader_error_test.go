@@ -0,0 +1,106 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInfoLog(t *testing.T) {
+	files := []string{"main.frag", "light.glsl"}
+	tests := []struct {
+		name        string
+		log         string
+		diagnostics []Diagnostic
+	}{
+		{
+			name: "mesa",
+			log:  "0:12(5): error: `foo' undeclared\n1:3(10): warning: unused variable\n",
+			diagnostics: []Diagnostic{
+				{File: "main.frag", Line: 12, Column: 5, Severity: SeverityError, Message: "`foo' undeclared", source: 0},
+				{File: "light.glsl", Line: 3, Column: 10, Severity: SeverityWarning, Message: "unused variable", source: 1},
+			},
+		},
+		{
+			name: "nvidia",
+			log:  "0(12) : error C1008: undefined variable \"foo\"\n1(4) : warning C7050: \"bar\" might be used before being initialized",
+			diagnostics: []Diagnostic{
+				{File: "main.frag", Line: 12, Severity: SeverityError, Message: "undefined variable \"foo\"", source: 0},
+				{File: "light.glsl", Line: 4, Severity: SeverityWarning, Message: "\"bar\" might be used before being initialized", source: 1},
+			},
+		},
+		{
+			name: "nvidia fatal",
+			log:  "0(1) : fatal error C9999: out of memory",
+			diagnostics: []Diagnostic{
+				{File: "main.frag", Line: 1, Severity: SeverityError, Message: "out of memory", source: 0},
+			},
+		},
+		{
+			name: "amd",
+			log:  "ERROR: 0:12: 'foo' : undeclared identifier\nWARNING: 1:7: 'bar' : unused\nERROR: 2 compilation errors.  No code generated.",
+			diagnostics: []Diagnostic{
+				{File: "main.frag", Line: 12, Severity: SeverityError, Message: "'foo' : undeclared identifier", source: 0},
+				{File: "light.glsl", Line: 7, Severity: SeverityWarning, Message: "'bar' : unused", source: 1},
+			},
+		},
+		{
+			name: "unknown source string",
+			log:  "5:2(1): error: message",
+			diagnostics: []Diagnostic{
+				{Line: 2, Column: 1, Severity: SeverityError, Message: "message", source: 5},
+			},
+		},
+		{
+			name: "unrecognized",
+			log:  "Vertex shader failed to compile with the following errors:\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diagnostics := parseInfoLog(test.log, files)
+			if !reflect.DeepEqual(diagnostics, test.diagnostics) {
+				t.Errorf("expected diagnostics:\n%+v\ngot:\n%+v", test.diagnostics, diagnostics)
+			}
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{Diagnostic{File: "main.frag", Line: 12, Column: 5, Severity: SeverityError, Message: "msg"}, "main.frag:12:5: error: msg"},
+		{Diagnostic{File: "main.frag", Line: 12, Severity: SeverityWarning, Message: "msg"}, "main.frag:12: warning: msg"},
+		{Diagnostic{Line: 3, Severity: SeverityError, Message: "msg", source: 2}, "2:3: error: msg"},
+	}
+	for _, test := range tests {
+		if str := test.diagnostic.String(); str != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, str)
+		}
+	}
+}
+
+func TestFormatDiagnostics(t *testing.T) {
+	err := &ShaderCompileError{
+		Source: strings.Join([]string{
+			"#version 410",
+			"#line 1 1",
+			"float a;",
+			"float b = foo;",
+			"#line 3 0",
+			"void main() {}",
+		}, "\n"),
+		Diagnostics: []Diagnostic{
+			{File: "light.glsl", Line: 2, Column: 11, Severity: SeverityError, Message: "undeclared", source: 1},
+		},
+	}
+	expected := "light.glsl:2:11: error: undeclared\n" +
+		"      1 | float a;\n" +
+		">     2 | float b = foo;\n" +
+		"        |           ^\n"
+	if formatted := err.FormatDiagnostics(1); formatted != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+}
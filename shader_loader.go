@@ -0,0 +1,113 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+var stageExtensions = map[string]uint32{
+	".vert": gl.VERTEX_SHADER,
+	".tesc": gl.TESS_CONTROL_SHADER,
+	".tese": gl.TESS_EVALUATION_SHADER,
+	".geom": gl.GEOMETRY_SHADER,
+	".frag": gl.FRAGMENT_SHADER,
+}
+
+// stageOrder is the order stages are compiled in when provided as a map.
+var stageOrder = map[uint32]int{
+	gl.VERTEX_SHADER:          0,
+	gl.TESS_CONTROL_SHADER:    1,
+	gl.TESS_EVALUATION_SHADER: 2,
+	gl.GEOMETRY_SHADER:        3,
+	gl.FRAGMENT_SHADER:        4,
+}
+
+// StageType returns the shader type inferred from the extension of the
+// provided path: `.vert`, `.tesc`, `.tese`, `.geom` or `.frag`.
+func StageType(name string) (uint32, error) {
+	ext := strings.ToLower(path.Ext(name))
+	typ, ok := stageExtensions[ext]
+	if !ok {
+		return 0, fmt.Errorf("cannot infer shader stage of `%s` from "+
+			"extension `%s`", name, ext)
+	}
+	return typ, nil
+}
+
+// NewShaderFromSource instantiates a new shader object from GLSL source keyed
+// by shader type, such as gl.VERTEX_SHADER. The sources are always treated as
+// source, never as file paths.
+func NewShaderFromSource(sources map[uint32]string, options ...ProgramOption) (*Shader, error) {
+	stages := make([]*ShaderStage, 0, len(sources))
+	for typ, source := range sources {
+		stages = append(stages, &ShaderStage{
+			Type:   typ,
+			Source: source,
+			loaded: true,
+		})
+	}
+	sortStages(stages)
+	return NewShaderProgram(stages, options...)
+}
+
+// NewShaderFromReaders instantiates a new shader object from GLSL source read
+// from the provided readers, keyed by shader type.
+func NewShaderFromReaders(readers map[uint32]io.Reader, options ...ProgramOption) (*Shader, error) {
+	sources := make(map[uint32]string, len(readers))
+	for typ, reader := range readers {
+		source, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s shader: %v",
+				stageName(typ), err)
+		}
+		sources[typ] = string(source)
+	}
+	return NewShaderFromSource(sources, options...)
+}
+
+// NewShaderFromFS instantiates a new shader object from the files at the
+// provided paths within the file system, such as an embed.FS. The stage of
+// each file is inferred from its extension, and `#include` directives are
+// resolved within the same file system. Use StagesFromFS with
+// NewShaderProgram to link with program options.
+func NewShaderFromFS(fsys fs.FS, paths ...string) (*Shader, error) {
+	stages, err := StagesFromFS(fsys, paths...)
+	if err != nil {
+		return nil, err
+	}
+	return NewShaderProgram(stages)
+}
+
+// StagesFromFS loads the files at the provided paths within the file system
+// as shader stages, inferring the stage of each from its extension. The
+// stages may then be linked with any program options, or used with
+// NewShaderVariants or a ProgramCache.
+func StagesFromFS(fsys fs.FS, paths ...string) ([]*ShaderStage, error) {
+	preprocessor := NewPreprocessor(fsys)
+	stages := make([]*ShaderStage, 0, len(paths))
+	for _, name := range paths {
+		typ, err := StageType(name)
+		if err != nil {
+			return nil, err
+		}
+		stage, err := preprocessor.Stage(typ, name)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	sortStages(stages)
+	return stages, nil
+}
+
+func sortStages(stages []*ShaderStage) {
+	sort.SliceStable(stages, func(i, j int) bool {
+		return stageOrder[stages[i].Type] < stageOrder[stages[j].Type]
+	})
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestStageType(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  uint32
+		err  bool
+	}{
+		{"shader.vert", gl.VERTEX_SHADER, false},
+		{"dir/shader.TESC", gl.TESS_CONTROL_SHADER, false},
+		{"shader.tese", gl.TESS_EVALUATION_SHADER, false},
+		{"shader.geom", gl.GEOMETRY_SHADER, false},
+		{"shader.frag", gl.FRAGMENT_SHADER, false},
+		{"shader.glsl", 0, true},
+		{"shader", 0, true},
+	}
+	for _, test := range tests {
+		typ, err := StageType(test.name)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil || typ != test.typ {
+			t.Errorf("%s: expected 0x%x, got 0x%x (%v)", test.name, test.typ, typ, err)
+		}
+	}
+}
+
+func TestStagesFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shader.frag":  {Data: []byte("#include \"common.glsl\"\nvoid main() {}\n")},
+		"shader.geom":  {Data: []byte("void main() {}\n")},
+		"shader.vert":  {Data: []byte("void main() {}\n")},
+		"common.glsl":  {Data: []byte("float common;\n")},
+		"shader.other": {Data: []byte("void main() {}\n")},
+	}
+	stages, err := StagesFromFS(fsys, "shader.frag", "shader.vert", "shader.geom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// stages are sorted into pipeline order
+	expected := []uint32{gl.VERTEX_SHADER, gl.GEOMETRY_SHADER, gl.FRAGMENT_SHADER}
+	if len(stages) != len(expected) {
+		t.Fatalf("expected %d stages, got %d", len(expected), len(stages))
+	}
+	for i, stage := range stages {
+		if stage.Type != expected[i] {
+			t.Errorf("stage %d: expected 0x%x, got 0x%x", i, expected[i], stage.Type)
+		}
+	}
+	if frag := stages[2]; len(frag.Files) != 2 || frag.Files[1] != "common.glsl" {
+		t.Errorf("expected fragment stage to include common.glsl, got %v", frag.Files)
+	}
+
+	if _, err := StagesFromFS(fsys, "shader.other"); err == nil {
+		t.Errorf("expected error for unknown extension")
+	}
+	if _, err := StagesFromFS(fsys, "missing.frag"); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
@@ -0,0 +1,221 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// ShaderStage represents a single stage of a shader program.
+type ShaderStage struct {
+	// Type is the shader type, such as gl.VERTEX_SHADER.
+	Type uint32
+	// Source is either the GLSL source of the stage, or the path to a file
+	// containing it.
+	Source string
+	// Files maps the source string numbers of `#line` directives in Source to
+	// file names. It is populated by the Preprocessor.
+	Files []string
+	// loaded stages always hold source rather than a path
+	loaded bool
+	// stages loaded from files retain how they were loaded
+	preprocessor *Preprocessor
+	path         string
+	// variant stages retain the defines injected into Source
+	defines *Defines
+}
+
+// ProgramOption configures how a shader program is linked.
+type ProgramOption func(*Shader)
+
+// Separable flags the program as separable so that it can be combined with
+// other separable programs in a ProgramPipeline. Separable programs do not
+// require a vertex stage.
+func Separable() ProgramOption {
+	return func(s *Shader) {
+		s.separable = true
+	}
+}
+
+// TransformFeedbackVaryings declares the outputs of the last vertex
+// processing stage to capture with transform feedback. The mode is either
+// gl.INTERLEAVED_ATTRIBS to capture all varyings into a single buffer, or
+// gl.SEPARATE_ATTRIBS to capture each varying into its own buffer.
+func TransformFeedbackVaryings(mode uint32, names ...string) ProgramOption {
+	return func(s *Shader) {
+		s.feedbackMode = mode
+		s.feedbackVaryings = names
+	}
+}
+
+// AttribLocation binds the named vertex attribute to the provided location,
+// for attributes declared without a `layout(location=...)` qualifier.
+func AttribLocation(name string, location uint32) ProgramOption {
+	return func(s *Shader) {
+		if s.attribLocations == nil {
+			s.attribLocations = make(map[string]uint32)
+		}
+		s.attribLocations[name] = location
+	}
+}
+
+// FragDataLocation binds the named fragment output to the provided color
+// number, for outputs declared without a `layout(location=...)` qualifier.
+func FragDataLocation(name string, color uint32) ProgramOption {
+	return func(s *Shader) {
+		if s.fragDataLocations == nil {
+			s.fragDataLocations = make(map[string]uint32)
+		}
+		s.fragDataLocations[name] = color
+	}
+}
+
+// NewShaderProgram instantiates a new shader object from the provided stages.
+// The stages are compiled in the order provided, validated as a combination
+// and then linked into a single program.
+func NewShaderProgram(stages []*ShaderStage, options ...ProgramOption) (*Shader, error) {
+	// create shader
+	shader := newShader(stages, options)
+	// validate stages before compiling anything
+	err := validateStages(stages, shader.separable)
+	if err != nil {
+		return nil, err
+	}
+	err = shader.build(stages)
+	if err != nil {
+		return nil, err
+	}
+	return shader, nil
+}
+
+// newShader returns a new unbuilt shader with the provided options applied.
+func newShader(stages []*ShaderStage, options []ProgramOption) *Shader {
+	shader := &Shader{
+		stages: stages,
+	}
+	for _, option := range options {
+		option(shader)
+	}
+	return shader
+}
+
+// config returns a new unbuilt shader with the same link configuration.
+func (s *Shader) config(stages []*ShaderStage) *Shader {
+	return &Shader{
+		stages:        stages,
+		retrievable:   s.retrievable,
+		separable:     s.separable,
+		blockBindings: s.blockBindings,
+		// transform feedback
+		feedbackMode:     s.feedbackMode,
+		feedbackVaryings: s.feedbackVaryings,
+		// explicit locations
+		attribLocations:   s.attribLocations,
+		fragDataLocations: s.fragDataLocations,
+	}
+}
+
+// linkKey returns a canonical string of the link configuration.
+func (s *Shader) linkKey() string {
+	return fmt.Sprintf("separable=%t;feedback=%d:%s;attribs=%s;outputs=%s",
+		s.separable, s.feedbackMode, strings.Join(s.feedbackVaryings, ","),
+		locationsKey(s.attribLocations), locationsKey(s.fragDataLocations))
+}
+
+func locationsKey(locations map[string]uint32) string {
+	keys := make([]string, 0, len(locations))
+	for name, location := range locations {
+		keys = append(keys, fmt.Sprintf("%s:%d", name, location))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// build compiles, attaches and links the provided stages.
+func (s *Shader) build(stages []*ShaderStage) error {
+	for _, stage := range stages {
+		// create shader stage
+		obj, err := s.createStage(stage)
+		if err != nil {
+			// delete previously compiled stages
+			s.deleteShaders()
+			s.Destroy()
+			return err
+		}
+		// attach shader stage
+		s.AttachShader(obj)
+	}
+	// link program
+	err := s.LinkProgram()
+	if err != nil {
+		s.Destroy()
+		return err
+	}
+	return nil
+}
+
+func (s *Shader) createStage(stage *ShaderStage) (uint32, error) {
+	if stage.loaded {
+		return s.compileShader(stage.Source, stage.Type, stage.Files)
+	}
+	return s.CreateShader(stage.Source, stage.Type)
+}
+
+// stageName returns a human readable name for a shader type.
+func stageName(typ uint32) string {
+	switch typ {
+	case gl.VERTEX_SHADER:
+		return "vertex"
+	case gl.TESS_CONTROL_SHADER:
+		return "tessellation control"
+	case gl.TESS_EVALUATION_SHADER:
+		return "tessellation evaluation"
+	case gl.GEOMETRY_SHADER:
+		return "geometry"
+	case gl.FRAGMENT_SHADER:
+		return "fragment"
+	}
+	return fmt.Sprintf("unknown (0x%x)", typ)
+}
+
+func validateStages(stages []*ShaderStage, separable bool) error {
+	if len(stages) == 0 {
+		return fmt.Errorf("no shader stages provided")
+	}
+	present := make(map[uint32]bool)
+	for i, stage := range stages {
+		if stage == nil {
+			return fmt.Errorf("shader stage %d is nil", i)
+		}
+		switch stage.Type {
+		case gl.VERTEX_SHADER,
+			gl.TESS_CONTROL_SHADER,
+			gl.TESS_EVALUATION_SHADER,
+			gl.GEOMETRY_SHADER,
+			gl.FRAGMENT_SHADER:
+		default:
+			return fmt.Errorf("shader type `0x%x` is not supported", stage.Type)
+		}
+		if present[stage.Type] {
+			return fmt.Errorf("%s stage provided more than once",
+				stageName(stage.Type))
+		}
+		present[stage.Type] = true
+	}
+	// separable programs may hold any subset of stages
+	if separable {
+		return nil
+	}
+	// a program must always have a vertex stage
+	if !present[gl.VERTEX_SHADER] {
+		return fmt.Errorf("program requires a vertex stage")
+	}
+	// a tessellation control stage has no effect without an evaluation stage
+	if present[gl.TESS_CONTROL_SHADER] && !present[gl.TESS_EVALUATION_SHADER] {
+		return fmt.Errorf("tessellation control stage requires a " +
+			"tessellation evaluation stage")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestValidateStages(t *testing.T) {
+	stage := func(typ uint32) *ShaderStage {
+		return &ShaderStage{Type: typ}
+	}
+	tests := []struct {
+		name      string
+		stages    []*ShaderStage
+		separable bool
+		valid     bool
+	}{
+		{"vertex and fragment", []*ShaderStage{stage(gl.VERTEX_SHADER), stage(gl.FRAGMENT_SHADER)}, false, true},
+		{"tessellation", []*ShaderStage{
+			stage(gl.VERTEX_SHADER),
+			stage(gl.TESS_CONTROL_SHADER),
+			stage(gl.TESS_EVALUATION_SHADER),
+		}, false, true},
+		{"separable fragment", []*ShaderStage{stage(gl.FRAGMENT_SHADER)}, true, true},
+		{"empty", nil, false, false},
+		{"nil stage", []*ShaderStage{stage(gl.VERTEX_SHADER), nil}, false, false},
+		{"nil separable stage", []*ShaderStage{nil}, true, false},
+		{"unsupported type", []*ShaderStage{stage(gl.COMPUTE_SHADER)}, false, false},
+		{"duplicate", []*ShaderStage{stage(gl.VERTEX_SHADER), stage(gl.VERTEX_SHADER)}, false, false},
+		{"no vertex", []*ShaderStage{stage(gl.FRAGMENT_SHADER)}, false, false},
+		{"control without evaluation", []*ShaderStage{
+			stage(gl.VERTEX_SHADER),
+			stage(gl.TESS_CONTROL_SHADER),
+		}, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateStages(test.stages, test.separable)
+			if test.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Defines represents an immutable set of preprocessor defines used to select
+// a shader variant.
+type Defines struct {
+	lines []string
+	key   string
+}
+
+// NewDefines instantiates and returns a new set of defines. Each define is
+// provided either as `NAME` or `NAME=VALUE`.
+func NewDefines(defines ...string) *Defines {
+	values := make(map[string]string)
+	for _, define := range defines {
+		name, value := define, ""
+		if eq := strings.Index(define, "="); eq != -1 {
+			name, value = define[:eq], define[eq+1:]
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		values[name] = strings.TrimSpace(value)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	keys := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = strings.TrimSpace(fmt.Sprintf("#define %s %s", name, values[name]))
+		keys[i] = name + "=" + values[name]
+	}
+	return &Defines{
+		lines: lines,
+		key:   strings.Join(keys, ";"),
+	}
+}
+
+// Key returns the canonical key of the set of defines.
+func (d *Defines) Key() string {
+	if d == nil {
+		return ""
+	}
+	return d.key
+}
+
+// Inject returns the provided source with the defines inserted after the
+// `#version` directive.
+func (d *Defines) Inject(source string) string {
+	if d == nil || len(d.lines) == 0 {
+		return source
+	}
+	lines := strings.Split(source, "\n")
+	// find the version directive, if any
+	version := -1
+	for i, line := range lines {
+		directive, _ := parseDirective(line)
+		if directive == "version" {
+			version = i
+			break
+		}
+	}
+	injected := make([]string, 0, len(lines)+len(d.lines)+1)
+	injected = append(injected, lines[:version+1]...)
+	injected = append(injected, d.lines...)
+	// restore the original line numbering
+	injected = append(injected, fmt.Sprintf("#line %d", version+2))
+	injected = append(injected, lines[version+1:]...)
+	return strings.Join(injected, "\n")
+}
+
+// ShaderVariants represents a cache of shader programs compiled from the same
+// stages with different sets of defines.
+type ShaderVariants struct {
+	stages   []*ShaderStage
+	options  []ProgramOption
+	variants map[string]*Shader
+	// failed holds the keys of variants that failed to build
+	failed map[string]bool
+	cache  *ProgramCache
+}
+
+// NewShaderVariants instantiates and returns a new shader variant cache for
+// the provided stages. No variants are compiled until they are requested.
+func NewShaderVariants(stages []*ShaderStage, options ...ProgramOption) (*ShaderVariants, error) {
+	err := validateStages(stages, newShader(stages, options).separable)
+	if err != nil {
+		return nil, err
+	}
+	// load the stage sources once up front
+	loaded := make([]*ShaderStage, len(stages))
+	for i, stage := range stages {
+		copied := *stage
+		if !copied.loaded {
+			if !isGLSL(stage.Source) {
+				// retain the path so the stage can be reloaded
+				copied.Files = []string{stage.Source}
+				copied.path = stage.Source
+			}
+			copied.Source, err = loadShaderSource(stage.Source)
+			if err != nil {
+				return nil, err
+			}
+			copied.loaded = true
+		}
+		loaded[i] = &copied
+	}
+	return &ShaderVariants{
+		stages:   loaded,
+		options:  options,
+		variants: make(map[string]*Shader),
+		failed:   make(map[string]bool),
+	}, nil
+}
+
+// SetCache sets a program cache to load and store compiled variants with.
+func (v *ShaderVariants) SetCache(cache *ProgramCache) {
+	v.cache = cache
+}
+
+// Variant returns the shader for the provided set of defines, compiling and
+// linking it on first request. A nil set of defines returns the base variant.
+// Variants that fail to build are not cached, and are rebuilt from the
+// reloaded stage files on the next request.
+func (v *ShaderVariants) Variant(defines *Defines) (*Shader, error) {
+	key := defines.Key()
+	shader, ok := v.variants[key]
+	if ok {
+		return shader, nil
+	}
+	if v.failed[key] {
+		// the stage files may have been fixed since the failure
+		err := v.reloadStages()
+		if err != nil {
+			return nil, err
+		}
+	}
+	stages := v.injectDefines(defines)
+	var err error
+	if v.cache != nil {
+		shader, err = v.cache.program(stages, defines, v.options)
+	} else {
+		shader, err = NewShaderProgram(stages, v.options...)
+	}
+	if err != nil {
+		v.failed[key] = true
+		return nil, err
+	}
+	delete(v.failed, key)
+	v.variants[key] = shader
+	return shader, nil
+}
+
+// Destroy deallocates all compiled variants.
+func (v *ShaderVariants) Destroy() {
+	for _, shader := range v.variants {
+		shader.Destroy()
+	}
+	v.variants = make(map[string]*Shader)
+	v.failed = make(map[string]bool)
+}
+
+// reloadStages re-reads the stages loaded from files.
+func (v *ShaderVariants) reloadStages() error {
+	stages := make([]*ShaderStage, len(v.stages))
+	for i, stage := range v.stages {
+		reloaded, err := stage.reload()
+		if err != nil {
+			return err
+		}
+		stages[i] = reloaded
+	}
+	v.stages = stages
+	return nil
+}
+
+func (v *ShaderVariants) injectDefines(defines *Defines) []*ShaderStage {
+	stages := make([]*ShaderStage, len(v.stages))
+	for i, stage := range v.stages {
+		copied := *stage
+		copied.Source = defines.Inject(stage.Source)
+		copied.defines = defines
+		stages[i] = &copied
+	}
+	return stages
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestNewDefinesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		defines []string
+		key     string
+	}{
+		{"empty", nil, ""},
+		{"flag", []string{"SHADOWS"}, "SHADOWS="},
+		{"value", []string{"LIGHTS=4"}, "LIGHTS=4"},
+		{"sorted", []string{"SKINNED", "LIGHTS=4"}, "LIGHTS=4;SKINNED="},
+		{"order independent", []string{"LIGHTS=4", "SKINNED"}, "LIGHTS=4;SKINNED="},
+		{"whitespace", []string{" LIGHTS = 4 "}, "LIGHTS=4"},
+		{"last wins", []string{"LIGHTS=2", "LIGHTS=4"}, "LIGHTS=4"},
+		{"blank name", []string{"", "=4"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := NewDefines(test.defines...).Key()
+			if key != test.key {
+				t.Errorf("expected key %q, got %q", test.key, key)
+			}
+		})
+	}
+}
+
+func TestDefinesInject(t *testing.T) {
+	tests := []struct {
+		name     string
+		defines  *Defines
+		source   string
+		expected string
+	}{
+		{
+			name:     "nil defines",
+			defines:  nil,
+			source:   "#version 410\nvoid main() {}",
+			expected: "#version 410\nvoid main() {}",
+		},
+		{
+			name:     "empty defines",
+			defines:  NewDefines(),
+			source:   "#version 410\nvoid main() {}",
+			expected: "#version 410\nvoid main() {}",
+		},
+		{
+			name:    "after version",
+			defines: NewDefines("SKINNED", "LIGHTS=4"),
+			source:  "#version 410\nvoid main() {}",
+			expected: "#version 410\n" +
+				"#define LIGHTS 4\n" +
+				"#define SKINNED\n" +
+				"#line 2\n" +
+				"void main() {}",
+		},
+		{
+			name:    "version after comments",
+			defines: NewDefines("SKINNED"),
+			source:  "// header\n\n  #version 410 core\nvoid main() {}",
+			expected: "// header\n\n  #version 410 core\n" +
+				"#define SKINNED\n" +
+				"#line 4\n" +
+				"void main() {}",
+		},
+		{
+			name:    "no version",
+			defines: NewDefines("SKINNED"),
+			source:  "void main() {}",
+			expected: "#define SKINNED\n" +
+				"#line 1\n" +
+				"void main() {}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			injected := test.defines.Inject(test.source)
+			if injected != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, injected)
+			}
+		})
+	}
+}
+
+func TestVariantRetriesFailures(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shader.vert")
+	err := os.WriteFile(path, []byte("void main() { v1; }\n"), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	variants, err := NewShaderVariants([]*ShaderStage{{Type: gl.VERTEX_SHADER, Source: path}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := NewDefines("SKINNED").Key()
+
+	// a failed variant re-reads its stage files before it is rebuilt
+	variants.failed[key] = true
+	err = os.Remove(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := variants.Variant(NewDefines("SKINNED")); err == nil {
+		t.Fatalf("expected error reloading a missing stage file")
+	}
+	if _, ok := variants.variants[key]; ok {
+		t.Errorf("expected failed variant not to be cached")
+	}
+
+	err = os.WriteFile(path, []byte("void main() { v2; }\n"), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = variants.reloadStages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(variants.stages[0].Source, "v2") {
+		t.Errorf("expected reloaded source, got:\n%s", variants.stages[0].Source)
+	}
+}
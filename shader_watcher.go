@@ -0,0 +1,203 @@
+package render
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Reload recompiles and relinks the shader in place from the stages it was
+// created with, re-reading any files they were loaded from. If compilation or
+// linking fails the current program is left untouched and remains usable.
+func (s *Shader) Reload() error {
+	if len(s.stages) == 0 {
+		return nil
+	}
+	// reload stage sources
+	stages := make([]*ShaderStage, len(s.stages))
+	for i, stage := range s.stages {
+		reloaded, err := stage.reload()
+		if err != nil {
+			return err
+		}
+		stages[i] = reloaded
+	}
+	// build the replacement program
+	err := validateStages(stages, s.separable)
+	if err != nil {
+		return err
+	}
+	shader := s.config(stages)
+	err = shader.build(stages)
+	if err != nil {
+		return err
+	}
+	// swap the replacement into place, state caches detect the new id
+	s.Destroy()
+	*s = *shader
+	return nil
+}
+
+// reload re-reads a stage loaded from a file, re-injecting any variant defines.
+// Stages that are read at build time are returned as is.
+func (stage *ShaderStage) reload() (*ShaderStage, error) {
+	var reloaded *ShaderStage
+	switch {
+	case stage.preprocessor != nil:
+		var err error
+		reloaded, err = stage.preprocessor.Stage(stage.Type, stage.path)
+		if err != nil {
+			return nil, err
+		}
+	case stage.loaded && stage.path != "":
+		source, err := loadShaderSource(stage.path)
+		if err != nil {
+			return nil, err
+		}
+		reloaded = &ShaderStage{
+			Type:   stage.Type,
+			Source: source,
+			Files:  []string{stage.path},
+			loaded: true,
+			path:   stage.path,
+		}
+	default:
+		return stage, nil
+	}
+	reloaded.Source = stage.defines.Inject(reloaded.Source)
+	reloaded.defines = stage.defines
+	return reloaded, nil
+}
+
+// DefaultWatchInterval is the default interval between polls of a shader
+// watcher.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+type watchedFile struct {
+	preprocessor *Preprocessor
+	name         string
+}
+
+func (w watchedFile) modTime() (time.Time, error) {
+	var info os.FileInfo
+	var err error
+	if w.preprocessor != nil {
+		info, err = fs.Stat(w.preprocessor.fsys, w.name)
+	} else {
+		info, err = os.Stat(w.name)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// ShaderWatcher polls the files behind a shader, including any resolved
+// includes, and reloads the shader in place when they change.
+type ShaderWatcher struct {
+	shader   *Shader
+	interval time.Duration
+	onError  func(error)
+	modTimes map[watchedFile]time.Time
+	lastPoll time.Time
+}
+
+// NewShaderWatcher instantiates and returns a new watcher for the provided
+// shader. Reload failures are reported to the provided callback, which may be
+// nil. Stages created from inline source are not watched.
+func NewShaderWatcher(shader *Shader, interval time.Duration, onError func(error)) *ShaderWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &ShaderWatcher{
+		shader:   shader,
+		interval: interval,
+		onError:  onError,
+		lastPoll: time.Now(),
+	}
+	w.modTimes = w.snapshot()
+	return w
+}
+
+// Poll checks the watched files for changes if the poll interval has elapsed,
+// and reloads the shader if any have changed. It must be called from the
+// thread owning the GL context, typically once per frame. It returns true if
+// the shader was reloaded.
+func (w *ShaderWatcher) Poll() bool {
+	now := time.Now()
+	if now.Sub(w.lastPoll) < w.interval {
+		return false
+	}
+	w.lastPoll = now
+	modTimes := w.snapshot()
+	if !w.changed(modTimes) {
+		return false
+	}
+	// record the new state so a failed reload is not retried until the files
+	// change again
+	w.modTimes = modTimes
+	err := w.shader.Reload()
+	if err != nil {
+		w.report(err)
+		return false
+	}
+	// the set of includes may have changed
+	w.modTimes = w.snapshot()
+	return true
+}
+
+func (w *ShaderWatcher) files() []watchedFile {
+	var files []watchedFile
+	for _, stage := range w.shader.stages {
+		if stage.preprocessor != nil {
+			for _, name := range stage.Files {
+				files = append(files, watchedFile{
+					preprocessor: stage.preprocessor,
+					name:         name,
+				})
+			}
+			continue
+		}
+		if stage.loaded && stage.path != "" {
+			files = append(files, watchedFile{
+				name: stage.path,
+			})
+			continue
+		}
+		if !stage.loaded && !isGLSL(stage.Source) {
+			files = append(files, watchedFile{
+				name: stage.Source,
+			})
+		}
+	}
+	return files
+}
+
+func (w *ShaderWatcher) snapshot() map[watchedFile]time.Time {
+	modTimes := make(map[watchedFile]time.Time)
+	for _, file := range w.files() {
+		modTime, err := file.modTime()
+		if err != nil {
+			// files are often briefly missing while being saved
+			continue
+		}
+		modTimes[file] = modTime
+	}
+	return modTimes
+}
+
+func (w *ShaderWatcher) changed(modTimes map[watchedFile]time.Time) bool {
+	for file, modTime := range modTimes {
+		prev, ok := w.modTimes[file]
+		if !ok || !prev.Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *ShaderWatcher) report(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
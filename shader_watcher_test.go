@@ -0,0 +1,104 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestReloadPreprocessedVariant(t *testing.T) {
+	fsys := fstest.MapFS{
+		"light.frag": {Data: []byte("#version 410\nvoid main() { v1; }\n")},
+	}
+	stage, err := NewPreprocessor(fsys).Stage(gl.FRAGMENT_SHADER, "light.frag")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	variants := &ShaderVariants{
+		stages: []*ShaderStage{stage},
+	}
+	stages := variants.injectDefines(NewDefines("LIGHTS=4"))
+
+	fsys["light.frag"] = &fstest.MapFile{Data: []byte("#version 410\nvoid main() { v2; }\n")}
+	reloaded, err := stages[0].reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(reloaded.Source, "v2") {
+		t.Errorf("expected reloaded source, got:\n%s", reloaded.Source)
+	}
+	if !strings.Contains(reloaded.Source, "#define LIGHTS 4") {
+		t.Errorf("expected define to survive reload, got:\n%s", reloaded.Source)
+	}
+	// a second reload must not inject the defines twice
+	again, err := reloaded.reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(again.Source, "#define LIGHTS 4") != 1 {
+		t.Errorf("expected a single define, got:\n%s", again.Source)
+	}
+}
+
+func TestReloadFileVariant(t *testing.T) {
+	dir := t.TempDir()
+	vert := filepath.Join(dir, "shader.vert")
+	frag := filepath.Join(dir, "shader.frag")
+	write := func(name, source string) {
+		if err := os.WriteFile(name, []byte(source), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	write(vert, "#version 410\nvoid main() {}\n")
+	write(frag, "#version 410\nvoid main() { v1; }\n")
+
+	variants, err := NewShaderVariants([]*ShaderStage{
+		{Type: gl.VERTEX_SHADER, Source: vert},
+		{Type: gl.FRAGMENT_SHADER, Source: frag},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stages := variants.injectDefines(NewDefines("SKINNED"))
+
+	// the variant stages are watched by their original paths
+	watcher := &ShaderWatcher{
+		shader: &Shader{
+			stages: stages,
+		},
+	}
+	files := watcher.files()
+	if len(files) != 2 || files[0].name != vert || files[1].name != frag {
+		t.Errorf("expected watched files %s and %s, got %v", vert, frag, files)
+	}
+
+	write(frag, "#version 410\nvoid main() { v2; }\n")
+	reloaded, err := stages[1].reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(reloaded.Source, "v2") {
+		t.Errorf("expected reloaded source, got:\n%s", reloaded.Source)
+	}
+	if !strings.Contains(reloaded.Source, "#define SKINNED") {
+		t.Errorf("expected define to survive reload, got:\n%s", reloaded.Source)
+	}
+}
+
+func TestReloadInlineStage(t *testing.T) {
+	stage := &ShaderStage{
+		Type:   gl.FRAGMENT_SHADER,
+		Source: "#version 410\nvoid main() {}\n",
+	}
+	reloaded, err := stage.reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reloaded != stage {
+		t.Errorf("expected inline stage to be returned as is")
+	}
+}
@@ -0,0 +1,487 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+type boundProgram struct {
+	program  uint32
+	pipeline uint32
+}
+
+func (b *boundProgram) Equals(other *boundProgram) bool {
+	if other == nil {
+		return false
+	}
+	// a program in use takes precedence over the bound pipeline
+	if b.program != 0 {
+		return b.program == other.program
+	}
+	return other.program == 0 && b.pipeline == other.pipeline
+}
+
+// StateCache tracks the GL state of a single context so that redundant state
+// changes can be skipped. Each context requires its own cache. If GL state is
+// modified outside of the library, call Invalidate or Sync before the next
+// draw.
+type StateCache struct {
+	program     *boundProgram
+	framebuffer *uint32
+	viewport    *Viewport
+	cullFace    *cullFace
+	depthMask   *depthMask
+	depthFunc   *depthFunc
+	// stencil state, front then back
+	stencilFuncs [2]*stencilFunc
+	stencilOps   [2]*stencilOp
+	stencilMasks [2]*stencilMask
+	// blend state, with per draw buffer state that was set individually
+	blendFunc      *blendFunc
+	blendFuncs     map[uint32]*blendFunc
+	blendEquation  *blendEquation
+	blendEquations map[uint32]*blendEquation
+	blendColor     *blendColor
+	// clear values
+	clearColor   *clearColor
+	clearDepth   *clearDepth
+	clearStencil *clearStencil
+	// known state of capabilities, absent if unknown
+	enables map[uint32]bool
+	// known state of indexed capabilities that were set individually, other
+	// indices share the state of the capability
+	enablesi map[indexedCap]bool
+	// capabilities that have been enabled through the cache
+	managed map[uint32]bool
+	// whether glInvalidateFramebuffer is available, nil if not yet queried
+	invalidate *bool
+}
+
+// NewStateCache instantiates and returns a new state cache. The cache begins
+// invalidated, so the first draw sets all state.
+func NewStateCache() *StateCache {
+	return &StateCache{
+		blendFuncs:     make(map[uint32]*blendFunc),
+		blendEquations: make(map[uint32]*blendEquation),
+		enables:        make(map[uint32]bool),
+		enablesi:       make(map[indexedCap]bool),
+		managed:        make(map[uint32]bool),
+	}
+}
+
+// Invalidate discards all cached state so that it is set unconditionally on
+// the next draw.
+func (c *StateCache) Invalidate() {
+	c.program = nil
+	c.framebuffer = nil
+	c.viewport = nil
+	c.blendFunc = nil
+	c.blendFuncs = make(map[uint32]*blendFunc)
+	c.blendEquation = nil
+	c.blendEquations = make(map[uint32]*blendEquation)
+	c.blendColor = nil
+	c.cullFace = nil
+	c.depthMask = nil
+	c.depthFunc = nil
+	c.stencilFuncs = [2]*stencilFunc{}
+	c.stencilOps = [2]*stencilOp{}
+	c.stencilMasks = [2]*stencilMask{}
+	c.clearColor = nil
+	c.clearDepth = nil
+	c.clearStencil = nil
+	c.enables = make(map[uint32]bool)
+	c.enablesi = make(map[indexedCap]bool)
+}
+
+// Sync reads the current GL state of the context back into the cache.
+func (c *StateCache) Sync() {
+	var program, pipeline, framebuffer int32
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.PROGRAM_PIPELINE_BINDING, &pipeline)
+	c.program = &boundProgram{
+		program:  uint32(program),
+		pipeline: uint32(pipeline),
+	}
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &framebuffer)
+	id := uint32(framebuffer)
+	c.framebuffer = &id
+
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	c.viewport = &Viewport{
+		X:      viewport[0],
+		Y:      viewport[1],
+		Width:  viewport[2],
+		Height: viewport[3],
+	}
+
+	c.syncBlend()
+
+	var mode, xfunc int32
+	gl.GetIntegerv(gl.CULL_FACE_MODE, &mode)
+	c.cullFace = &cullFace{
+		mode: uint32(mode),
+	}
+	var flag bool
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &flag)
+	c.depthMask = &depthMask{
+		flag: flag,
+	}
+	gl.GetIntegerv(gl.DEPTH_FUNC, &xfunc)
+	c.depthFunc = &depthFunc{
+		xfunc: uint32(xfunc),
+	}
+	c.syncStencil()
+
+	var color [4]float32
+	gl.GetFloatv(gl.COLOR_CLEAR_VALUE, &color[0])
+	c.clearColor = &clearColor{
+		r: color[0],
+		g: color[1],
+		b: color[2],
+		a: color[3],
+	}
+	var depth float64
+	gl.GetDoublev(gl.DEPTH_CLEAR_VALUE, &depth)
+	c.clearDepth = &clearDepth{
+		depth: depth,
+	}
+	var stencil int32
+	gl.GetIntegerv(gl.STENCIL_CLEAR_VALUE, &stencil)
+	c.clearStencil = &clearStencil{
+		s: stencil,
+	}
+
+	c.enables = make(map[uint32]bool)
+	for state := range c.managed {
+		c.enables[state] = gl.IsEnabled(state)
+	}
+	enablesi := make(map[indexedCap]bool)
+	for ic := range c.enablesi {
+		enablesi[ic] = gl.IsEnabledi(ic.state, ic.index)
+	}
+	c.enablesi = enablesi
+}
+
+// syncBlend reads the blend state of each draw buffer back into the cache.
+func (c *StateCache) syncBlend() {
+	var maxDrawBuffers int32
+	gl.GetIntegerv(gl.MAX_DRAW_BUFFERS, &maxDrawBuffers)
+	c.blendFuncs = make(map[uint32]*blendFunc)
+	c.blendEquations = make(map[uint32]*blendEquation)
+	for i := uint32(0); i < uint32(maxDrawBuffers); i++ {
+		var srcRGB, dstRGB, srcAlpha, dstAlpha, modeRGB, modeAlpha int32
+		gl.GetIntegeri_v(gl.BLEND_SRC_RGB, i, &srcRGB)
+		gl.GetIntegeri_v(gl.BLEND_DST_RGB, i, &dstRGB)
+		gl.GetIntegeri_v(gl.BLEND_SRC_ALPHA, i, &srcAlpha)
+		gl.GetIntegeri_v(gl.BLEND_DST_ALPHA, i, &dstAlpha)
+		gl.GetIntegeri_v(gl.BLEND_EQUATION_RGB, i, &modeRGB)
+		gl.GetIntegeri_v(gl.BLEND_EQUATION_ALPHA, i, &modeAlpha)
+		b := &blendFunc{
+			srcRGB:   uint32(srcRGB),
+			dstRGB:   uint32(dstRGB),
+			srcAlpha: uint32(srcAlpha),
+			dstAlpha: uint32(dstAlpha),
+		}
+		e := &blendEquation{
+			modeRGB:   uint32(modeRGB),
+			modeAlpha: uint32(modeAlpha),
+		}
+		// draw buffers that differ from the first are recorded individually
+		if i == 0 {
+			c.blendFunc = b
+			c.blendEquation = e
+			continue
+		}
+		if !b.Equals(c.blendFunc) {
+			c.blendFuncs[i] = b
+		}
+		if !e.Equals(c.blendEquation) {
+			c.blendEquations[i] = e
+		}
+	}
+	var color [4]float32
+	gl.GetFloatv(gl.BLEND_COLOR, &color[0])
+	c.blendColor = &blendColor{
+		r: color[0],
+		g: color[1],
+		b: color[2],
+		a: color[3],
+	}
+}
+
+// useProgram activates the program unless it is already in use.
+func (c *StateCache) useProgram(program Program) {
+	bound := program.bound()
+	if !bound.Equals(c.program) {
+		program.Use()
+		c.program = bound
+	}
+}
+
+// bindFrameBuffer binds the framebuffer, or the default framebuffer if nil,
+// unless it is already bound.
+func (c *StateCache) bindFrameBuffer(framebuffer *FrameBuffer) {
+	id := uint32(0)
+	if framebuffer != nil {
+		id = framebuffer.id
+	}
+	if c.framebuffer != nil && *c.framebuffer == id {
+		return
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, id)
+	c.framebuffer = &id
+}
+
+// setEnables enables the provided capabilities, and the indexed capabilities
+// for their indices only, disabling any others that were previously enabled
+// through the cache.
+func (c *StateCache) setEnables(enables []uint32, enablesi []indexedCap) {
+	desired := make(map[uint32]bool, len(enables))
+	for _, state := range enables {
+		desired[state] = true
+		c.managed[state] = true
+	}
+	desiredi := make(map[uint32]map[uint32]bool)
+	for _, ic := range enablesi {
+		c.managed[ic.state] = true
+		if desired[ic.state] {
+			// already enabled for all indices
+			continue
+		}
+		if desiredi[ic.state] == nil {
+			desiredi[ic.state] = make(map[uint32]bool)
+		}
+		desiredi[ic.state][ic.index] = true
+	}
+	for state := range c.managed {
+		switch {
+		case desired[state]:
+			c.enable(state)
+		case desiredi[state] != nil:
+			c.enableIndices(state, desiredi[state])
+		default:
+			// disable stale state
+			c.disable(state)
+		}
+	}
+}
+
+// enabled returns whether the capability is enabled for all indices, and
+// whether that is known.
+func (c *StateCache) enabled(state uint32) (bool, bool) {
+	enabled, known := c.enables[state]
+	if !known {
+		return false, false
+	}
+	for ic, e := range c.enablesi {
+		if ic.state == state && e != enabled {
+			return false, false
+		}
+	}
+	return enabled, true
+}
+
+// setEnabled records the capability as enabled or disabled for all indices.
+func (c *StateCache) setEnabled(state uint32, enabled bool) {
+	c.enables[state] = enabled
+	for ic := range c.enablesi {
+		if ic.state == state {
+			delete(c.enablesi, ic)
+		}
+	}
+}
+
+// enable enables the capability unless it is known to be enabled.
+func (c *StateCache) enable(state uint32) {
+	if enabled, known := c.enabled(state); !known || !enabled {
+		gl.Enable(state)
+		c.setEnabled(state, true)
+	}
+}
+
+// disable disables the capability unless it is known to be disabled.
+func (c *StateCache) disable(state uint32) {
+	if enabled, known := c.enabled(state); !known || enabled {
+		gl.Disable(state)
+		c.setEnabled(state, false)
+	}
+}
+
+// enableIndices enables the capability for the provided indices only.
+func (c *StateCache) enableIndices(state uint32, indices map[uint32]bool) {
+	if enabled, known := c.enables[state]; !known || enabled {
+		gl.Disable(state)
+		c.setEnabled(state, false)
+	}
+	for ic, enabled := range c.enablesi {
+		if ic.state == state && enabled && !indices[ic.index] {
+			gl.Disablei(state, ic.index)
+			c.enablesi[ic] = false
+		}
+	}
+	for index := range indices {
+		ic := indexedCap{
+			state: state,
+			index: index,
+		}
+		// indices not set individually are known to be disabled
+		if !c.enablesi[ic] {
+			gl.Enablei(state, index)
+			c.enablesi[ic] = true
+		}
+	}
+}
+
+func (c *StateCache) setViewport(viewport *Viewport) {
+	if viewport != nil && !viewport.Equals(c.viewport) {
+		gl.Viewport(
+			viewport.X,
+			viewport.Y,
+			viewport.Width,
+			viewport.Height)
+		c.viewport = viewport
+	}
+}
+
+// setBlendFunc sets the blend func of all draw buffers, other than those
+// with a blend func of their own.
+func (c *StateCache) setBlendFunc(b *blendFunc, buffers map[uint32]*blendFunc) {
+	if b == nil {
+		return
+	}
+	if !b.Equals(c.blendFunc) {
+		gl.BlendFuncSeparate(b.srcRGB, b.dstRGB, b.srcAlpha, b.dstAlpha)
+		c.blendFunc = b
+		c.blendFuncs = make(map[uint32]*blendFunc)
+	}
+	// restore stale draw buffers
+	for buffer, current := range c.blendFuncs {
+		if _, ok := buffers[buffer]; ok {
+			continue
+		}
+		if !b.Equals(current) {
+			gl.BlendFuncSeparatei(buffer, b.srcRGB, b.dstRGB, b.srcAlpha, b.dstAlpha)
+		}
+		delete(c.blendFuncs, buffer)
+	}
+	for buffer, bi := range buffers {
+		current, ok := c.blendFuncs[buffer]
+		if !ok {
+			current = c.blendFunc
+		}
+		if !bi.Equals(current) {
+			gl.BlendFuncSeparatei(buffer, bi.srcRGB, bi.dstRGB, bi.srcAlpha, bi.dstAlpha)
+		}
+		c.blendFuncs[buffer] = bi
+	}
+}
+
+// setBlendEquation sets the blend equation of all draw buffers, other than
+// those with a blend equation of their own.
+func (c *StateCache) setBlendEquation(e *blendEquation, buffers map[uint32]*blendEquation) {
+	if e == nil {
+		return
+	}
+	if !e.Equals(c.blendEquation) {
+		gl.BlendEquationSeparate(e.modeRGB, e.modeAlpha)
+		c.blendEquation = e
+		c.blendEquations = make(map[uint32]*blendEquation)
+	}
+	// restore stale draw buffers
+	for buffer, current := range c.blendEquations {
+		if _, ok := buffers[buffer]; ok {
+			continue
+		}
+		if !e.Equals(current) {
+			gl.BlendEquationSeparatei(buffer, e.modeRGB, e.modeAlpha)
+		}
+		delete(c.blendEquations, buffer)
+	}
+	for buffer, ei := range buffers {
+		current, ok := c.blendEquations[buffer]
+		if !ok {
+			current = c.blendEquation
+		}
+		if !ei.Equals(current) {
+			gl.BlendEquationSeparatei(buffer, ei.modeRGB, ei.modeAlpha)
+		}
+		c.blendEquations[buffer] = ei
+	}
+}
+
+func (c *StateCache) setBlendColor(b *blendColor) {
+	if b != nil && !b.Equals(c.blendColor) {
+		gl.BlendColor(b.r, b.g, b.b, b.a)
+		c.blendColor = b
+	}
+}
+
+func (c *StateCache) setCullFace(f *cullFace) {
+	if f != nil && !f.Equals(c.cullFace) {
+		gl.CullFace(f.mode)
+		c.cullFace = f
+	}
+}
+
+func (c *StateCache) setDepthMask(d *depthMask) {
+	if d != nil && !d.Equals(c.depthMask) {
+		gl.DepthMask(d.flag)
+		c.depthMask = d
+	}
+}
+
+func (c *StateCache) setDepthFunc(d *depthFunc) {
+	if d != nil && !d.Equals(c.depthFunc) {
+		gl.DepthFunc(d.xfunc)
+		c.depthFunc = d
+	}
+}
+
+func (c *StateCache) setClearColor(color *clearColor) {
+	if color != nil && !color.Equals(c.clearColor) {
+		gl.ClearColor(color.r, color.g, color.b, color.a)
+		c.clearColor = color
+	}
+}
+
+func (c *StateCache) setClearDepth(depth *clearDepth) {
+	if depth != nil && !depth.Equals(c.clearDepth) {
+		gl.ClearDepth(depth.depth)
+		c.clearDepth = depth
+	}
+}
+
+func (c *StateCache) setClearStencil(stencil *clearStencil) {
+	if stencil != nil && !stencil.Equals(c.clearStencil) {
+		gl.ClearStencil(stencil.s)
+		c.clearStencil = stencil
+	}
+}
+
+// invalidateSupported returns whether glInvalidateFramebuffer is available,
+// requiring either GL 4.3 or the ARB_invalidate_subdata extension.
+func (c *StateCache) invalidateSupported() bool {
+	if c.invalidate != nil {
+		return *c.invalidate
+	}
+	supported := false
+	var major, minor, numExtensions int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 4 || (major == 4 && minor >= 3) {
+		supported = true
+	} else {
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &numExtensions)
+		for i := int32(0); i < numExtensions; i++ {
+			name := gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i)))
+			if strings.TrimSpace(name) == "GL_ARB_invalidate_subdata" {
+				supported = true
+				break
+			}
+		}
+	}
+	c.invalidate = &supported
+	return supported
+}
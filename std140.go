@@ -0,0 +1,402 @@
+package render
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Std140Member represents the computed std140 layout of a single member of
+// an encoded struct. Arrays of scalars, vectors and matrices are reported
+// once, with a `[0]` suffix, matching the names reflected by GL.
+type Std140Member struct {
+	Name   string
+	Offset int32
+	Size   int32
+}
+
+// EncodeStd140 encodes a Go struct into a byte slice following the GLSL
+// std140 layout rules.
+//
+// Supported field types are float32, float64, int32, uint32 and bool
+// scalars, fixed size arrays, and nested structs. Arrays of 2 to 4 scalars
+// are encoded as vectors, and arrays of 9 or 16 scalars as 3x3 and 4x4
+// column-major matrices. Fields are named by their `glsl:"..."` tag, or
+// their field name if untagged. Tag options override how arrays are
+// interpreted: `glsl:"name,array"` encodes a scalar array, and
+// `glsl:"name,mat2x3"` or similar encodes a matrix, or an array of matrices,
+// of the given dimensions.
+func EncodeStd140(value interface{}) ([]byte, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, fmt.Errorf("%T is nil", value)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("cannot encode a nil value")
+	}
+	typ, err := std140TypeOf(v.Type(), "")
+	if err != nil {
+		return nil, err
+	}
+	if typ.kind != std140Struct {
+		return nil, fmt.Errorf("%s is not a struct", v.Type())
+	}
+	data := make([]byte, typ.size)
+	typ.encode(data, 0, v)
+	return data, nil
+}
+
+// Std140Layout returns the computed std140 layout of the members of a Go
+// struct, in declaration order, along with the total size of the struct.
+func Std140Layout(value interface{}) ([]Std140Member, int32, error) {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, 0, fmt.Errorf("%T is not a struct", value)
+	}
+	typ, err := std140TypeOf(t, "")
+	if err != nil {
+		return nil, 0, err
+	}
+	var members []Std140Member
+	typ.members("", 0, &members)
+	return members, typ.size, nil
+}
+
+// ValidateStd140 compares the computed std140 layout of a Go struct against
+// the reflected offsets of a uniform block, returning an error describing the
+// first member that disagrees.
+func ValidateStd140(value interface{}, block *UniformBlockDescriptor) error {
+	members, _, err := Std140Layout(value)
+	if err != nil {
+		return err
+	}
+	matched := make(map[string]bool)
+	for _, member := range members {
+		name, offset, ok := lookupBlockMember(block, member.Name)
+		if !ok {
+			return fmt.Errorf("member `%s` at offset %d not found in block `%s`",
+				member.Name, member.Offset, block.Name)
+		}
+		if offset != member.Offset {
+			return fmt.Errorf("member `%s` of block `%s` is at offset %d, "+
+				"computed offset is %d", member.Name, block.Name, offset,
+				member.Offset)
+		}
+		matched[name] = true
+	}
+	var unmatched []string
+	for name := range block.Offsets {
+		if !matched[name] {
+			unmatched = append(unmatched, name)
+		}
+	}
+	if len(unmatched) > 0 {
+		// report the member with the lowest offset
+		sort.Slice(unmatched, func(i, j int) bool {
+			return block.Offsets[unmatched[i]] < block.Offsets[unmatched[j]]
+		})
+		return fmt.Errorf("member `%s` of block `%s` has no matching field",
+			unmatched[0], block.Name)
+	}
+	return nil
+}
+
+// lookupBlockMember finds the reflected offset for a computed member name,
+// accounting for block name prefixes and untagged field capitalization.
+func lookupBlockMember(block *UniformBlockDescriptor, name string) (string, int32, bool) {
+	parts := strings.Split(name, ".")
+	for i := range parts {
+		parts[i] = lowerFirst(parts[i])
+	}
+	lowered := strings.Join(parts, ".")
+	candidates := []string{
+		name,
+		lowered,
+		block.Name + "." + name,
+		block.Name + "." + lowered,
+	}
+	for _, candidate := range candidates {
+		offset, ok := block.Offsets[candidate]
+		if ok {
+			return candidate, offset, true
+		}
+	}
+	return "", 0, false
+}
+
+type std140Kind int
+
+const (
+	std140Scalar std140Kind = iota
+	std140Vector
+	std140Matrix
+	std140Array
+	std140Struct
+)
+
+type std140Field struct {
+	name   string
+	index  int
+	offset int32
+	typ    *std140Type
+}
+
+type std140Type struct {
+	kind std140Kind
+	// scalar kind of scalars, vectors and matrices
+	scalar reflect.Kind
+	// components of vectors, rows of matrices
+	components int32
+	// columns of matrices
+	columns int32
+	// length and element type of arrays
+	length int32
+	elem   *std140Type
+	stride int32
+	// fields of structs
+	fields []std140Field
+	align  int32
+	size   int32
+}
+
+func std140TypeOf(t reflect.Type, option string) (*std140Type, error) {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		return &std140Type{
+			kind:   std140Scalar,
+			scalar: t.Kind(),
+			align:  4,
+			size:   4,
+		}, nil
+	case reflect.Float64:
+		return &std140Type{
+			kind:   std140Scalar,
+			scalar: t.Kind(),
+			align:  8,
+			size:   8,
+		}, nil
+	case reflect.Array:
+		return std140ArrayTypeOf(t, option)
+	case reflect.Struct:
+		return std140StructTypeOf(t)
+	}
+	return nil, fmt.Errorf("type %s is not supported by std140", t)
+}
+
+func std140ArrayTypeOf(t reflect.Type, option string) (*std140Type, error) {
+	length := int32(t.Len())
+	// matrix options of arrays of matrices apply to the elements
+	elemOption := ""
+	if t.Elem().Kind() == reflect.Array && strings.HasPrefix(option, "mat") {
+		elemOption = option
+	}
+	elem, err := std140TypeOf(t.Elem(), elemOption)
+	if err != nil {
+		return nil, err
+	}
+	if elem.kind == std140Scalar && option != "array" {
+		// arrays of scalars are vectors or matrices unless tagged otherwise
+		columns, rows := int32(0), int32(0)
+		switch {
+		case strings.HasPrefix(option, "mat"):
+			dims := strings.Split(option[3:], "x")
+			columns = int32(atoi(dims[0]))
+			rows = columns
+			if len(dims) > 1 {
+				rows = int32(atoi(dims[1]))
+			}
+			if columns < 2 || columns > 4 || rows < 2 || rows > 4 {
+				return nil, fmt.Errorf("invalid matrix option `%s`", option)
+			}
+		case length == 9:
+			columns, rows = 3, 3
+		case length == 16:
+			columns, rows = 4, 4
+		case length >= 2 && length <= 4:
+			return std140VectorType(elem, length), nil
+		}
+		if columns > 0 {
+			if columns*rows != length {
+				return nil, fmt.Errorf("matrix option `%s` does not match "+
+					"array length %d", option, length)
+			}
+			// matrices are laid out as arrays of column vectors
+			column := std140VectorType(elem, rows)
+			stride := alignUp(column.size, max32(column.align, 16))
+			return &std140Type{
+				kind:       std140Matrix,
+				scalar:     elem.scalar,
+				components: rows,
+				columns:    columns,
+				elem:       column,
+				stride:     stride,
+				align:      max32(column.align, 16),
+				size:       stride * columns,
+			}, nil
+		}
+	}
+	// array elements are aligned to at least a vec4
+	align := max32(elem.align, 16)
+	stride := alignUp(elem.size, align)
+	return &std140Type{
+		kind:   std140Array,
+		length: length,
+		elem:   elem,
+		stride: stride,
+		align:  align,
+		size:   stride * length,
+	}, nil
+}
+
+func std140VectorType(scalar *std140Type, components int32) *std140Type {
+	// vec3 is aligned like vec4
+	align := scalar.size * 2
+	if components > 2 {
+		align = scalar.size * 4
+	}
+	return &std140Type{
+		kind:       std140Vector,
+		scalar:     scalar.scalar,
+		components: components,
+		elem:       scalar,
+		align:      align,
+		size:       scalar.size * components,
+	}
+}
+
+func std140StructTypeOf(t reflect.Type) (*std140Type, error) {
+	typ := &std140Type{
+		kind:  std140Struct,
+		align: 16,
+	}
+	offset := int32(0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name, option := field.Name, ""
+		tag := field.Tag.Get("glsl")
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			parts := strings.SplitN(tag, ",", 2)
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) > 1 {
+				option = parts[1]
+			}
+		}
+		fieldType, err := std140TypeOf(field.Type, option)
+		if err != nil {
+			return nil, fmt.Errorf("field `%s`: %v", field.Name, err)
+		}
+		offset = alignUp(offset, fieldType.align)
+		typ.fields = append(typ.fields, std140Field{
+			name:   name,
+			index:  i,
+			offset: offset,
+			typ:    fieldType,
+		})
+		offset += fieldType.size
+		typ.align = max32(typ.align, fieldType.align)
+	}
+	// structs are padded to a multiple of their alignment
+	typ.size = alignUp(offset, typ.align)
+	return typ, nil
+}
+
+func (t *std140Type) members(name string, offset int32, members *[]Std140Member) {
+	switch t.kind {
+	case std140Struct:
+		for _, field := range t.fields {
+			fieldName := field.name
+			if name != "" {
+				fieldName = name + "." + field.name
+			}
+			field.typ.members(fieldName, offset+field.offset, members)
+		}
+	case std140Array:
+		if t.elem.kind == std140Struct {
+			// members of arrays of structs are reported per element
+			for i := int32(0); i < t.length; i++ {
+				elemName := name + "[" + strconv.Itoa(int(i)) + "]"
+				t.elem.members(elemName, offset+i*t.stride, members)
+			}
+			return
+		}
+		*members = append(*members, Std140Member{
+			Name:   name + "[0]",
+			Offset: offset,
+			Size:   t.size,
+		})
+	default:
+		*members = append(*members, Std140Member{
+			Name:   name,
+			Offset: offset,
+			Size:   t.size,
+		})
+	}
+}
+
+func (t *std140Type) encode(data []byte, offset int32, v reflect.Value) {
+	switch t.kind {
+	case std140Scalar:
+		encodeScalar(data[offset:], v)
+	case std140Vector:
+		for i := int32(0); i < t.components; i++ {
+			encodeScalar(data[offset+i*t.elem.size:], v.Index(int(i)))
+		}
+	case std140Matrix:
+		// values are column-major, each column is padded to the stride
+		for c := int32(0); c < t.columns; c++ {
+			for r := int32(0); r < t.components; r++ {
+				encodeScalar(data[offset+c*t.stride+r*t.elem.elem.size:],
+					v.Index(int(c*t.components+r)))
+			}
+		}
+	case std140Array:
+		for i := int32(0); i < t.length; i++ {
+			t.elem.encode(data, offset+i*t.stride, v.Index(int(i)))
+		}
+	case std140Struct:
+		for _, field := range t.fields {
+			field.typ.encode(data, offset+field.offset, v.Field(field.index))
+		}
+	}
+}
+
+func encodeScalar(data []byte, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Float32:
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		binary.LittleEndian.PutUint64(data, math.Float64bits(v.Float()))
+	case reflect.Int32:
+		binary.LittleEndian.PutUint32(data, uint32(int32(v.Int())))
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(data, uint32(v.Uint()))
+	case reflect.Bool:
+		binary.LittleEndian.PutUint32(data, uint32(boolToInt32(v.Bool())))
+	}
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
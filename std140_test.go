@@ -0,0 +1,271 @@
+package render
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAlignUp(t *testing.T) {
+	tests := []struct {
+		size      int32
+		alignment int32
+		expected  int32
+	}{
+		{0, 16, 0},
+		{1, 16, 16},
+		{16, 16, 16},
+		{17, 16, 32},
+		{12, 8, 16},
+		{12, 0, 12},
+		{12, -4, 12},
+		{100, 256, 256},
+	}
+	for _, test := range tests {
+		if aligned := alignUp(test.size, test.alignment); aligned != test.expected {
+			t.Errorf("alignUp(%d, %d): expected %d, got %d",
+				test.size, test.alignment, test.expected, aligned)
+		}
+	}
+}
+
+type std140Light struct {
+	Position [3]float32
+	Radius   float32
+}
+
+func TestStd140Layout(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		members []Std140Member
+		size    int32
+	}{
+		{
+			name: "vec3 followed by float",
+			value: struct {
+				A [3]float32 `glsl:"a"`
+				B float32    `glsl:"b"`
+			}{},
+			members: []Std140Member{{"a", 0, 12}, {"b", 12, 4}},
+			size:    16,
+		},
+		{
+			name: "float followed by vec2 and vec3",
+			value: struct {
+				A float32    `glsl:"a"`
+				B [2]float32 `glsl:"b"`
+				C [3]float32 `glsl:"c"`
+			}{},
+			members: []Std140Member{{"a", 0, 4}, {"b", 8, 8}, {"c", 16, 12}},
+			size:    32,
+		},
+		{
+			name: "scalar array",
+			value: struct {
+				A [3]float32 `glsl:"a,array"`
+				B int32      `glsl:"b"`
+			}{},
+			members: []Std140Member{{"a[0]", 0, 48}, {"b", 48, 4}},
+			size:    64,
+		},
+		{
+			name: "vec3 array",
+			value: struct {
+				A [2][3]float32 `glsl:"a"`
+			}{},
+			members: []Std140Member{{"a[0]", 0, 32}},
+			size:    32,
+		},
+		{
+			name: "matrices",
+			value: struct {
+				A float32     `glsl:"a"`
+				M [9]float32  `glsl:"m"`
+				N [16]float32 `glsl:"n"`
+				P [6]float32  `glsl:"p,mat2x3"`
+				Q [6]float32  `glsl:"q,mat3x2"`
+			}{},
+			members: []Std140Member{
+				{"a", 0, 4},
+				{"m", 16, 48},
+				{"n", 64, 64},
+				{"p", 128, 32},
+				{"q", 160, 48},
+			},
+			size: 208,
+		},
+		{
+			name: "matrix array",
+			value: struct {
+				M [2][4]float32 `glsl:"m,mat2"`
+			}{},
+			members: []Std140Member{{"m[0]", 0, 64}},
+			size:    64,
+		},
+		{
+			name: "doubles",
+			value: struct {
+				A float32    `glsl:"a"`
+				B float64    `glsl:"b"`
+				C [3]float64 `glsl:"c"`
+			}{},
+			members: []Std140Member{{"a", 0, 4}, {"b", 8, 8}, {"c", 32, 24}},
+			size:    64,
+		},
+		{
+			name: "nested struct",
+			value: struct {
+				A     float32     `glsl:"a"`
+				Light std140Light `glsl:"light"`
+				B     float32     `glsl:"b"`
+			}{},
+			members: []Std140Member{
+				{"a", 0, 4},
+				{"light.Position", 16, 12},
+				{"light.Radius", 28, 4},
+				{"b", 32, 4},
+			},
+			size: 48,
+		},
+		{
+			name: "struct array",
+			value: struct {
+				Lights [2]std140Light `glsl:"lights"`
+			}{},
+			members: []Std140Member{
+				{"lights[0].Position", 0, 12},
+				{"lights[0].Radius", 12, 4},
+				{"lights[1].Position", 16, 12},
+				{"lights[1].Radius", 28, 4},
+			},
+			size: 32,
+		},
+		{
+			name: "skipped and unexported fields",
+			value: struct {
+				A      float32 `glsl:"-"`
+				b      float32
+				Scale  float32
+				Active bool
+			}{},
+			members: []Std140Member{{"Scale", 0, 4}, {"Active", 4, 4}},
+			size:    16,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			members, size, err := Std140Layout(test.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(members, test.members) {
+				t.Errorf("expected members %v, got %v", test.members, members)
+			}
+			if size != test.size {
+				t.Errorf("expected size %d, got %d", test.size, size)
+			}
+		})
+	}
+}
+
+func TestEncodeStd140(t *testing.T) {
+	value := &struct {
+		A float32    `glsl:"a"`
+		M [6]float32 `glsl:"m,mat2x3"`
+		B int32      `glsl:"b"`
+		C bool       `glsl:"c"`
+		D float64    `glsl:"d"`
+	}{
+		A: 1,
+		M: [6]float32{2, 3, 4, 5, 6, 7},
+		B: -8,
+		C: true,
+		D: 9.5,
+	}
+	data, err := EncodeStd140(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) != 64 {
+		t.Fatalf("expected 64 bytes, got %d", len(data))
+	}
+	float := func(offset int) float32 {
+		return math.Float32frombits(binary.LittleEndian.Uint32(data[offset:]))
+	}
+	// columns of the matrix are padded to 16 bytes
+	floats := map[int]float32{0: 1, 16: 2, 20: 3, 24: 4, 32: 5, 36: 6, 40: 7}
+	for offset, expected := range floats {
+		if v := float(offset); v != expected {
+			t.Errorf("expected %v at offset %d, got %v", expected, offset, v)
+		}
+	}
+	if v := int32(binary.LittleEndian.Uint32(data[48:])); v != -8 {
+		t.Errorf("expected -8 at offset 48, got %d", v)
+	}
+	if v := binary.LittleEndian.Uint32(data[52:]); v != 1 {
+		t.Errorf("expected true at offset 52, got %d", v)
+	}
+	if v := math.Float64frombits(binary.LittleEndian.Uint64(data[56:])); v != 9.5 {
+		t.Errorf("expected 9.5 at offset 56, got %v", v)
+	}
+}
+
+func TestEncodeStd140Errors(t *testing.T) {
+	var nilPtr *std140Light
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"nil", nil},
+		{"nil pointer", nilPtr},
+		{"not a struct", float32(1)},
+		{"unsupported field", struct{ A string }{}},
+		{"invalid matrix option", struct {
+			M [6]float32 `glsl:"m,mat5"`
+		}{}},
+		{"mismatched matrix option", struct {
+			M [6]float32 `glsl:"m,mat3"`
+		}{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := EncodeStd140(test.value); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateStd140(t *testing.T) {
+	type block struct {
+		Color [4]float32
+		Scale float32
+	}
+	tests := []struct {
+		name    string
+		offsets map[string]int32
+		valid   bool
+	}{
+		{"matching", map[string]int32{"color": 0, "scale": 16}, true},
+		{"block prefix", map[string]int32{"Material.color": 0, "Material.scale": 16}, true},
+		{"wrong offset", map[string]int32{"color": 0, "scale": 20}, false},
+		{"missing member", map[string]int32{"color": 0}, false},
+		{"extra member", map[string]int32{"color": 0, "scale": 16, "shine": 20}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateStd140(block{}, &UniformBlockDescriptor{
+				Name:    "Material",
+				Offsets: test.offsets,
+			})
+			if test.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
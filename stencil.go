@@ -0,0 +1,134 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// stencil state is stored per face, front then back
+var stencilFaces = [2]uint32{gl.FRONT, gl.BACK}
+
+type stencilFunc struct {
+	xfunc uint32
+	ref   int32
+	mask  uint32
+}
+
+func (s *stencilFunc) Equals(other *stencilFunc) bool {
+	return other != nil &&
+		s.xfunc == other.xfunc &&
+		s.ref == other.ref &&
+		s.mask == other.mask
+}
+
+type stencilOp struct {
+	sfail  uint32
+	dpfail uint32
+	dppass uint32
+}
+
+func (s *stencilOp) Equals(other *stencilOp) bool {
+	return other != nil &&
+		s.sfail == other.sfail &&
+		s.dpfail == other.dpfail &&
+		s.dppass == other.dppass
+}
+
+type stencilMask struct {
+	mask uint32
+}
+
+func (s *stencilMask) Equals(other *stencilMask) bool {
+	return other != nil &&
+		s.mask == other.mask
+}
+
+// faceIndices returns the indices of the stencil state for the provided face,
+// one of gl.FRONT, gl.BACK or gl.FRONT_AND_BACK.
+func faceIndices(face uint32) []int {
+	switch face {
+	case gl.FRONT:
+		return []int{0}
+	case gl.BACK:
+		return []int{1}
+	}
+	return []int{0, 1}
+}
+
+func (c *StateCache) setStencilFunc(funcs [2]*stencilFunc) {
+	for i, s := range funcs {
+		if s != nil && !s.Equals(c.stencilFuncs[i]) {
+			gl.StencilFuncSeparate(stencilFaces[i], s.xfunc, s.ref, s.mask)
+			c.stencilFuncs[i] = s
+		}
+	}
+}
+
+func (c *StateCache) setStencilOp(ops [2]*stencilOp) {
+	for i, s := range ops {
+		if s != nil && !s.Equals(c.stencilOps[i]) {
+			gl.StencilOpSeparate(stencilFaces[i], s.sfail, s.dpfail, s.dppass)
+			c.stencilOps[i] = s
+		}
+	}
+}
+
+func (c *StateCache) setStencilMask(masks [2]*stencilMask) {
+	for i, s := range masks {
+		if s != nil && !s.Equals(c.stencilMasks[i]) {
+			gl.StencilMaskSeparate(stencilFaces[i], s.mask)
+			c.stencilMasks[i] = s
+		}
+	}
+}
+
+// syncStencil reads the stencil state of both faces back into the cache.
+func (c *StateCache) syncStencil() {
+	params := [2][7]uint32{
+		{
+			gl.STENCIL_FUNC,
+			gl.STENCIL_REF,
+			gl.STENCIL_VALUE_MASK,
+			gl.STENCIL_FAIL,
+			gl.STENCIL_PASS_DEPTH_FAIL,
+			gl.STENCIL_PASS_DEPTH_PASS,
+			gl.STENCIL_WRITEMASK,
+		},
+		{
+			gl.STENCIL_BACK_FUNC,
+			gl.STENCIL_BACK_REF,
+			gl.STENCIL_BACK_VALUE_MASK,
+			gl.STENCIL_BACK_FAIL,
+			gl.STENCIL_BACK_PASS_DEPTH_FAIL,
+			gl.STENCIL_BACK_PASS_DEPTH_PASS,
+			gl.STENCIL_BACK_WRITEMASK,
+		},
+	}
+	for i, names := range params {
+		var values [7]int32
+		for j, name := range names {
+			gl.GetIntegerv(name, &values[j])
+		}
+		c.stencilFuncs[i] = &stencilFunc{
+			xfunc: uint32(values[0]),
+			ref:   values[1],
+			mask:  uint32(values[2]),
+		}
+		c.stencilOps[i] = &stencilOp{
+			sfail:  uint32(values[3]),
+			dpfail: uint32(values[4]),
+			dppass: uint32(values[5]),
+		}
+		c.stencilMasks[i] = &stencilMask{
+			mask: uint32(values[6]),
+		}
+	}
+}
+
+// enableStencilWrites sets the stencil write mask of both faces so that
+// stencil clears are not masked.
+func (c *StateCache) enableStencilWrites() {
+	all := &stencilMask{
+		mask: 0xffffffff,
+	}
+	c.setStencilMask([2]*stencilMask{all, all})
+}
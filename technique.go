@@ -1,29 +1,55 @@
 package render
 
 import (
-	"github.com/go-gl/gl/v4.1-core/gl"
-)
+	"fmt"
 
-var (
-	prevBlendFunc   *blendFunc
-	prevCullFace    *cullFace
-	prevDepthMask   *depthMask
-	prevDepthFunc   *depthFunc
-	prevViewport    *Viewport
-	prevShader      *Shader
-	prevFrameBuffer *FrameBuffer
-	prevEnables     = make(map[uint32]bool)
+	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type blendFunc struct {
-	sfactor uint32
-	dfactor uint32
+	srcRGB   uint32
+	dstRGB   uint32
+	srcAlpha uint32
+	dstAlpha uint32
 }
 
 func (b *blendFunc) Equals(other *blendFunc) bool {
 	return other != nil &&
-		b.sfactor == other.sfactor &&
-		b.dfactor == other.dfactor
+		b.srcRGB == other.srcRGB &&
+		b.dstRGB == other.dstRGB &&
+		b.srcAlpha == other.srcAlpha &&
+		b.dstAlpha == other.dstAlpha
+}
+
+type blendEquation struct {
+	modeRGB   uint32
+	modeAlpha uint32
+}
+
+func (b *blendEquation) Equals(other *blendEquation) bool {
+	return other != nil &&
+		b.modeRGB == other.modeRGB &&
+		b.modeAlpha == other.modeAlpha
+}
+
+type blendColor struct {
+	r float32
+	g float32
+	b float32
+	a float32
+}
+
+func (b *blendColor) Equals(other *blendColor) bool {
+	return other != nil &&
+		b.r == other.r &&
+		b.g == other.g &&
+		b.b == other.b &&
+		b.a == other.a
+}
+
+type indexedCap struct {
+	state uint32
+	index uint32
 }
 
 type cullFace struct {
@@ -60,26 +86,75 @@ type clearColor struct {
 	a float32
 }
 
+func (c *clearColor) Equals(other *clearColor) bool {
+	return other != nil &&
+		c.r == other.r &&
+		c.g == other.g &&
+		c.b == other.b &&
+		c.a == other.a
+}
+
+type clearDepth struct {
+	depth float64
+}
+
+func (c *clearDepth) Equals(other *clearDepth) bool {
+	return other != nil &&
+		c.depth == other.depth
+}
+
+type clearStencil struct {
+	s int32
+}
+
+func (c *clearStencil) Equals(other *clearStencil) bool {
+	return other != nil &&
+		c.s == other.s
+}
+
 // Technique represents a render technique.
 type Technique struct {
-	enables     []uint32
-	shader      *Shader
-	viewport    *Viewport
-	framebuffer *FrameBuffer
-	blendFunc   *blendFunc
-	cullFace    *cullFace
-	depthMask   *depthMask
-	depthFunc   *depthFunc
-	clearColor  *clearColor
+	enables   []uint32
+	enablesi  []indexedCap
+	program   Program
+	viewport  *Viewport
+	blendFunc *blendFunc
+	// per draw buffer blend state, overriding blendFunc and blendEquation
+	blendFuncs     map[uint32]*blendFunc
+	blendEquation  *blendEquation
+	blendEquations map[uint32]*blendEquation
+	blendColor     *blendColor
+	cullFace       *cullFace
+	depthMask      *depthMask
+	depthFunc      *depthFunc
+	// stencil state, front then back
+	stencilFuncs [2]*stencilFunc
+	stencilOps   [2]*stencilOp
+	stencilMasks [2]*stencilMask
+	feedback     *TransformFeedback
+	// clears
+	clearMask    uint32
+	clearColor   *clearColor
+	clearDepth   *clearDepth
+	clearStencil *clearStencil
+	clearBuffers []*clearBuffer
 }
 
 // NewTechnique instantiates and returns a new technique instance.
 func NewTechnique() *Technique {
-	return &Technique{
+	t := &Technique{
 		blendFunc: &blendFunc{
-			sfactor: gl.ONE,
-			dfactor: gl.ZERO,
+			srcRGB:   gl.ONE,
+			dstRGB:   gl.ZERO,
+			srcAlpha: gl.ONE,
+			dstAlpha: gl.ZERO,
+		},
+		blendFuncs: make(map[uint32]*blendFunc),
+		blendEquation: &blendEquation{
+			modeRGB:   gl.FUNC_ADD,
+			modeAlpha: gl.FUNC_ADD,
 		},
+		blendEquations: make(map[uint32]*blendEquation),
 		cullFace: &cullFace{
 			mode: gl.BACK,
 		},
@@ -90,6 +165,10 @@ func NewTechnique() *Technique {
 			xfunc: gl.LESS,
 		},
 	}
+	t.StencilFunc(gl.ALWAYS, 0, 0xffffffff)
+	t.StencilOp(gl.KEEP, gl.KEEP, gl.KEEP)
+	t.StencilMask(0xffffffff)
+	return t
 }
 
 // Enable enables the rendering states for the technique.
@@ -97,21 +176,108 @@ func (t *Technique) Enable(enable uint32) {
 	t.enables = append(t.enables, enable)
 }
 
+// Enablei enables the indexed rendering state for the technique, such as
+// gl.BLEND for a single draw buffer.
+func (t *Technique) Enablei(enable uint32, index uint32) {
+	t.enablesi = append(t.enablesi, indexedCap{
+		state: enable,
+		index: index,
+	})
+}
+
 // Shader sets the shader for the technique.
 func (t *Technique) Shader(shader *Shader) {
-	t.shader = shader
+	t.program = shader
+}
+
+// Pipeline sets a program pipeline for the technique in place of a shader.
+func (t *Technique) Pipeline(pipeline *ProgramPipeline) {
+	t.program = pipeline
 }
 
-// Viewport sets the viewport for the technique.
+// TransformFeedback sets a transform feedback object to capture the output of
+// the technique. Capturing begins after the program is in use and ends once
+// all commands are drawn.
+func (t *Technique) TransformFeedback(feedback *TransformFeedback) {
+	t.feedback = feedback
+}
+
+// Viewport sets the viewport for the technique, overriding the viewport of
+// any render pass it is drawn in.
 func (t *Technique) Viewport(viewport *Viewport) {
 	t.viewport = viewport
 }
 
 // BlendFunc sets the blend func for the technique.
 func (t *Technique) BlendFunc(sfactor uint32, dfactor uint32) {
+	t.BlendFuncSeparate(sfactor, dfactor, sfactor, dfactor)
+}
+
+// BlendFuncSeparate sets separate rgb and alpha blend funcs for the technique.
+func (t *Technique) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
 	t.blendFunc = &blendFunc{
-		sfactor: sfactor,
-		dfactor: dfactor,
+		srcRGB:   srcRGB,
+		dstRGB:   dstRGB,
+		srcAlpha: srcAlpha,
+		dstAlpha: dstAlpha,
+	}
+}
+
+// BlendFunci sets the blend func of a single draw buffer for the technique,
+// overriding the blend func of the technique for that buffer.
+func (t *Technique) BlendFunci(drawBuffer uint32, sfactor uint32, dfactor uint32) {
+	t.BlendFuncSeparatei(drawBuffer, sfactor, dfactor, sfactor, dfactor)
+}
+
+// BlendFuncSeparatei sets separate rgb and alpha blend funcs of a single draw
+// buffer for the technique.
+func (t *Technique) BlendFuncSeparatei(drawBuffer uint32, srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	t.blendFuncs[drawBuffer] = &blendFunc{
+		srcRGB:   srcRGB,
+		dstRGB:   dstRGB,
+		srcAlpha: srcAlpha,
+		dstAlpha: dstAlpha,
+	}
+}
+
+// BlendEquation sets the blend equation for the technique, such as
+// gl.FUNC_ADD, gl.MIN or gl.MAX.
+func (t *Technique) BlendEquation(mode uint32) {
+	t.BlendEquationSeparate(mode, mode)
+}
+
+// BlendEquationSeparate sets separate rgb and alpha blend equations for the
+// technique.
+func (t *Technique) BlendEquationSeparate(modeRGB, modeAlpha uint32) {
+	t.blendEquation = &blendEquation{
+		modeRGB:   modeRGB,
+		modeAlpha: modeAlpha,
+	}
+}
+
+// BlendEquationi sets the blend equation of a single draw buffer for the
+// technique, overriding the blend equation of the technique for that buffer.
+func (t *Technique) BlendEquationi(drawBuffer uint32, mode uint32) {
+	t.BlendEquationSeparatei(drawBuffer, mode, mode)
+}
+
+// BlendEquationSeparatei sets separate rgb and alpha blend equations of a
+// single draw buffer for the technique.
+func (t *Technique) BlendEquationSeparatei(drawBuffer uint32, modeRGB, modeAlpha uint32) {
+	t.blendEquations[drawBuffer] = &blendEquation{
+		modeRGB:   modeRGB,
+		modeAlpha: modeAlpha,
+	}
+}
+
+// BlendColor sets the constant blend color for the technique, used by the
+// gl.CONSTANT_COLOR and gl.CONSTANT_ALPHA blend factors.
+func (t *Technique) BlendColor(r, g, b, a float32) {
+	t.blendColor = &blendColor{
+		r: r,
+		g: g,
+		b: b,
+		a: a,
 	}
 }
 
@@ -136,7 +302,56 @@ func (t *Technique) DepthFunc(xfunc uint32) {
 	}
 }
 
-// ClearColor sets the clear color for the frame.
+// StencilFunc sets the stencil test function, reference value and mask for
+// both faces.
+func (t *Technique) StencilFunc(xfunc uint32, ref int32, mask uint32) {
+	t.StencilFuncSeparate(gl.FRONT_AND_BACK, xfunc, ref, mask)
+}
+
+// StencilFuncSeparate sets the stencil test function, reference value and mask
+// for the provided face, one of gl.FRONT, gl.BACK or gl.FRONT_AND_BACK.
+func (t *Technique) StencilFuncSeparate(face uint32, xfunc uint32, ref int32, mask uint32) {
+	for _, i := range faceIndices(face) {
+		t.stencilFuncs[i] = &stencilFunc{
+			xfunc: xfunc,
+			ref:   ref,
+			mask:  mask,
+		}
+	}
+}
+
+// StencilOp sets the stencil test actions for both faces.
+func (t *Technique) StencilOp(sfail, dpfail, dppass uint32) {
+	t.StencilOpSeparate(gl.FRONT_AND_BACK, sfail, dpfail, dppass)
+}
+
+// StencilOpSeparate sets the stencil test actions for the provided face.
+func (t *Technique) StencilOpSeparate(face uint32, sfail, dpfail, dppass uint32) {
+	for _, i := range faceIndices(face) {
+		t.stencilOps[i] = &stencilOp{
+			sfail:  sfail,
+			dpfail: dpfail,
+			dppass: dppass,
+		}
+	}
+}
+
+// StencilMask sets the stencil write mask for both faces.
+func (t *Technique) StencilMask(mask uint32) {
+	t.StencilMaskSeparate(gl.FRONT_AND_BACK, mask)
+}
+
+// StencilMaskSeparate sets the stencil write mask for the provided face.
+func (t *Technique) StencilMaskSeparate(face uint32, mask uint32) {
+	for _, i := range faceIndices(face) {
+		t.stencilMasks[i] = &stencilMask{
+			mask: mask,
+		}
+	}
+}
+
+// ClearColor sets the clear color for the technique and clears the color
+// buffers at the start of each draw.
 func (t *Technique) ClearColor(r, g, b, a float32) {
 	t.clearColor = &clearColor{
 		r: r,
@@ -144,83 +359,131 @@ func (t *Technique) ClearColor(r, g, b, a float32) {
 		b: b,
 		a: a,
 	}
+	t.clearMask |= gl.COLOR_BUFFER_BIT
 }
 
-// Draw renders all commands using the technique.
-func (t *Technique) Draw(commands []*Command) error {
-	t.setup()
-	for _, command := range commands {
-		err := command.Execute(t.shader)
-		if err != nil {
-			return err
-		}
+// ClearDepth sets the clear depth for the technique and clears the depth
+// buffer at the start of each draw.
+func (t *Technique) ClearDepth(depth float64) {
+	t.clearDepth = &clearDepth{
+		depth: depth,
 	}
-	return nil
+	t.clearMask |= gl.DEPTH_BUFFER_BIT
 }
 
-func (t *Technique) setup() {
-
-	// bind framebuffer
-	if t.framebuffer == nil && prevFrameBuffer != nil {
-		prevFrameBuffer.Unbind()
-	}
-	if t.framebuffer != nil && t.framebuffer != prevFrameBuffer {
-		t.framebuffer.Bind()
-		prevFrameBuffer = t.framebuffer
+// ClearStencil sets the clear stencil value for the technique and clears the
+// stencil buffer at the start of each draw.
+func (t *Technique) ClearStencil(s int32) {
+	t.clearStencil = &clearStencil{
+		s: s,
 	}
+	t.clearMask |= gl.STENCIL_BUFFER_BIT
+}
+
+// ClearMask sets which buffers are cleared at the start of each draw, as a
+// combination of gl.COLOR_BUFFER_BIT, gl.DEPTH_BUFFER_BIT and
+// gl.STENCIL_BUFFER_BIT. Buffers without a clear value set are cleared to the
+// current GL clear value. A mask of 0 leaves the target untouched.
+func (t *Technique) ClearMask(mask uint32) {
+	t.clearMask = mask
+}
 
-	// use shader
-	if prevShader != t.shader {
-		t.shader.Use()
-		prevShader = t.shader
+// setClearBuffer sets the clear value of a draw buffer, replacing any value
+// previously set for it.
+func (t *Technique) setClearBuffer(clear *clearBuffer) {
+	for i, existing := range t.clearBuffers {
+		if existing.drawBuffer == clear.drawBuffer {
+			t.clearBuffers[i] = clear
+			return
+		}
 	}
+	t.clearBuffers = append(t.clearBuffers, clear)
+}
 
-	// track previous enables to determine which are stale
-	staleEnables := make(map[uint32]bool)
-	for state := range prevEnables {
-		staleEnables[state] = true
+// ClearBufferColor clears the color attachment of the provided draw buffer
+// index to a float value at the start of each draw.
+func (t *Technique) ClearBufferColor(drawBuffer int32, r, g, b, a float32) {
+	t.setClearBuffer(&clearBuffer{
+		drawBuffer: drawBuffer,
+		typ:        gl.FLOAT,
+		f:          [4]float32{r, g, b, a},
+	})
+}
+
+// ClearBufferColori clears the color attachment of the provided draw buffer
+// index to a signed integer value at the start of each draw.
+func (t *Technique) ClearBufferColori(drawBuffer int32, r, g, b, a int32) {
+	t.setClearBuffer(&clearBuffer{
+		drawBuffer: drawBuffer,
+		typ:        gl.INT,
+		i:          [4]int32{r, g, b, a},
+	})
+}
+
+// ClearBufferColorui clears the color attachment of the provided draw buffer
+// index to an unsigned integer value at the start of each draw.
+func (t *Technique) ClearBufferColorui(drawBuffer int32, r, g, b, a uint32) {
+	t.setClearBuffer(&clearBuffer{
+		drawBuffer: drawBuffer,
+		typ:        gl.UNSIGNED_INT,
+		ui:         [4]uint32{r, g, b, a},
+	})
+}
+
+// Draw renders all commands using the technique into the default
+// framebuffer, skipping any state changes the cache of the current context
+// records as redundant. The cache is required. Use a RenderPass to draw into
+// a framebuffer.
+func (t *Technique) Draw(cache *StateCache, commands []*Command) error {
+	if cache == nil {
+		return fmt.Errorf("technique requires a state cache")
 	}
+	return t.draw(cache, nil, nil, commands)
+}
 
-	// enable state
-	for _, state := range t.enables {
-		if !prevEnables[state] {
-			gl.Enable(state)
-			prevEnables[state] = true
+func (t *Technique) draw(cache *StateCache, framebuffer *FrameBuffer, viewport *Viewport, commands []*Command) error {
+	t.setup(cache, framebuffer, viewport)
+	if t.feedback != nil {
+		t.feedback.Begin(cache)
+		defer t.feedback.End(cache)
+	}
+	for _, command := range commands {
+		err := command.Execute(t.program)
+		if err != nil {
+			return err
 		}
-		delete(staleEnables, state)
 	}
+	return nil
+}
 
-	// disable stale state
-	for state := range staleEnables {
-		gl.Disable(state)
-		delete(prevEnables, state)
-	}
+func (t *Technique) setup(cache *StateCache, framebuffer *FrameBuffer, viewport *Viewport) {
+	// bind framebuffer
+	cache.bindFrameBuffer(framebuffer)
+
+	// use program
+	cache.useProgram(t.program)
+
+	// enable state, disabling any stale state
+	cache.setEnables(t.enables, t.enablesi)
+
+	// clear target, honoring the scissor test and before the write masks are
+	// set
+	t.clear(cache)
 
 	// update state functions
-	if t.blendFunc != nil && !t.blendFunc.Equals(prevBlendFunc) {
-		gl.BlendFunc(t.blendFunc.sfactor, t.blendFunc.dfactor)
-		prevBlendFunc = t.blendFunc
-	}
-	if t.cullFace != nil && !t.cullFace.Equals(prevCullFace) {
-		gl.CullFace(t.cullFace.mode)
-		prevCullFace = t.cullFace
-	}
-	if t.depthMask != nil && !t.depthMask.Equals(prevDepthMask) {
-		gl.DepthMask(t.depthMask.flag)
-		prevDepthMask = t.depthMask
-	}
-	if t.depthFunc != nil && !t.depthFunc.Equals(prevDepthFunc) {
-		gl.DepthFunc(t.depthFunc.xfunc)
-		prevDepthFunc = t.depthFunc
-	}
+	cache.setBlendFunc(t.blendFunc, t.blendFuncs)
+	cache.setBlendEquation(t.blendEquation, t.blendEquations)
+	cache.setBlendColor(t.blendColor)
+	cache.setCullFace(t.cullFace)
+	cache.setDepthMask(t.depthMask)
+	cache.setDepthFunc(t.depthFunc)
+	cache.setStencilFunc(t.stencilFuncs)
+	cache.setStencilOp(t.stencilOps)
+	cache.setStencilMask(t.stencilMasks)
 
-	// update viewport
-	if t.viewport != nil && !t.viewport.Equals(prevViewport) {
-		gl.Viewport(
-			t.viewport.X,
-			t.viewport.Y,
-			t.viewport.Width,
-			t.viewport.Height)
-		prevViewport = t.viewport
+	// update viewport, preferring the technique viewport over the target
+	if t.viewport != nil {
+		viewport = t.viewport
 	}
+	cache.setViewport(viewport)
 }
@@ -0,0 +1,43 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+func TestTechniqueClearBuffers(t *testing.T) {
+	technique := NewTechnique()
+	technique.ClearBufferColor(0, 1, 0, 0, 1)
+	technique.ClearBufferColori(1, 1, 2, 3, 4)
+	// clearing the same draw buffer again replaces its clear value
+	technique.ClearBufferColor(0, 0, 1, 0, 1)
+	technique.ClearBufferColorui(1, 5, 6, 7, 8)
+	technique.ClearBufferColor(0, 0, 0, 1, 1)
+
+	expected := []*clearBuffer{
+		{drawBuffer: 0, typ: gl.FLOAT, f: [4]float32{0, 0, 1, 1}},
+		{drawBuffer: 1, typ: gl.UNSIGNED_INT, ui: [4]uint32{5, 6, 7, 8}},
+	}
+	if !reflect.DeepEqual(technique.clearBuffers, expected) {
+		t.Errorf("expected %+v, got %+v", expected, technique.clearBuffers)
+	}
+}
+
+func TestNilStateCache(t *testing.T) {
+	technique := NewTechnique()
+	if err := technique.Draw(nil, nil); err == nil {
+		t.Errorf("expected error drawing technique without a cache")
+	}
+	pass := NewRenderPass(nil)
+	if err := pass.Begin(nil); err == nil {
+		t.Errorf("expected error beginning pass without a cache")
+	}
+	if err := pass.Draw(nil, technique, nil); err == nil {
+		t.Errorf("expected error drawing pass without a cache")
+	}
+	if err := pass.End(nil); err == nil {
+		t.Errorf("expected error ending pass without a cache")
+	}
+}
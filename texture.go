@@ -80,8 +80,32 @@ func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TexturePa
 		format:         gl.RGBA,
 		internalFormat: gl.RGBA,
 	}
-	gl.GenTextures(1, &texture.id)
-	gl.BindTexture(gl.TEXTURE_2D, texture.id)
+	// get pointer
+	var data unsafe.Pointer
+	if rgba != nil {
+		data = gl.Ptr(rgba)
+	}
+	texture.create(data, params)
+	return texture
+}
+
+// NewDepthStencilTexture returns a new texture with a 24 bit depth and 8 bit
+// stencil component, to be attached to gl.DEPTH_STENCIL_ATTACHMENT.
+func NewDepthStencilTexture(width uint32, height uint32, params *TextureParams) *Texture {
+	texture := &Texture{
+		width:          width,
+		height:         height,
+		typ:            gl.UNSIGNED_INT_24_8,
+		format:         gl.DEPTH_STENCIL,
+		internalFormat: gl.DEPTH24_STENCIL8,
+	}
+	texture.create(nil, params)
+	return texture
+}
+
+func (t *Texture) create(data unsafe.Pointer, params *TextureParams) {
+	gl.GenTextures(1, &t.id)
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
 	// default params
 	if params == nil {
 		params = &TextureParams{}
@@ -104,22 +128,16 @@ func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TexturePa
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, params.WrapS)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, params.WrapT)
 
-	// get pointer
-	var data unsafe.Pointer
-	if rgba != nil {
-		data = gl.Ptr(rgba)
-	}
-
 	// buffer texture
 	gl.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
-		texture.internalFormat,
-		int32(texture.width),
-		int32(texture.height),
+		t.internalFormat,
+		int32(t.width),
+		int32(t.height),
 		0,
-		texture.format,
-		texture.typ,
+		t.format,
+		t.typ,
 		data)
 
 	// generate mipmaps
@@ -130,7 +148,6 @@ func NewRGBATexture(rgba []uint8, width uint32, height uint32, params *TexturePa
 		gl.GenerateMipmap(gl.TEXTURE_2D)
 	}
 	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return texture
 }
 
 // Width returns the width of the texture.
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// TransformFeedback represents a transform feedback object capturing the
+// outputs of the vertex processing stages into vertex buffers.
+type TransformFeedback struct {
+	id      uint32
+	query   uint32
+	buffers []*VertexBuffer
+	mode    uint32
+	discard bool
+}
+
+// NewTransformFeedback instantiates and returns a new transform feedback
+// object capturing primitives of the provided mode, one of gl.POINTS,
+// gl.LINES or gl.TRIANGLES, into the provided buffers. Buffers are bound to
+// consecutive binding indices, a single buffer is used for interleaved
+// varyings, and one per varying for separate varyings. The buffers must be
+// allocated large enough to hold the captured output.
+func NewTransformFeedback(mode uint32, buffers ...*VertexBuffer) *TransformFeedback {
+	t := &TransformFeedback{
+		buffers: buffers,
+		mode:    mode,
+	}
+	gl.GenTransformFeedbacks(1, &t.id)
+	gl.GenQueries(1, &t.query)
+	return t
+}
+
+// RasterizerDiscard sets whether primitives are discarded before
+// rasterization while capturing, so that nothing is drawn.
+func (t *TransformFeedback) RasterizerDiscard(discard bool) {
+	t.discard = discard
+}
+
+// Begin starts capturing. The program declaring the captured varyings must
+// already be in use, and may not be changed until End is called. The cache of
+// the current context tracks rasterizer discard.
+func (t *TransformFeedback) Begin(cache *StateCache) {
+	gl.BindTransformFeedback(gl.TRANSFORM_FEEDBACK, t.id)
+	// bind buffers, which may have been reallocated since the last capture
+	for i, buffer := range t.buffers {
+		gl.BindBufferBase(gl.TRANSFORM_FEEDBACK_BUFFER, uint32(i), buffer.id)
+	}
+	if t.discard {
+		cache.enable(gl.RASTERIZER_DISCARD)
+	}
+	gl.BeginQuery(gl.TRANSFORM_FEEDBACK_PRIMITIVES_WRITTEN, t.query)
+	gl.BeginTransformFeedback(t.mode)
+}
+
+// End stops capturing.
+func (t *TransformFeedback) End(cache *StateCache) {
+	gl.EndTransformFeedback()
+	gl.EndQuery(gl.TRANSFORM_FEEDBACK_PRIMITIVES_WRITTEN)
+	if t.discard {
+		cache.disable(gl.RASTERIZER_DISCARD)
+	}
+	gl.BindTransformFeedback(gl.TRANSFORM_FEEDBACK, 0)
+}
+
+// PrimitivesWritten returns the number of primitives written by the last
+// capture, blocking until the result is available.
+func (t *TransformFeedback) PrimitivesWritten() uint32 {
+	var written uint32
+	gl.GetQueryObjectuiv(t.query, gl.QUERY_RESULT, &written)
+	return written
+}
+
+// Buffers returns the buffers the transform feedback captures into.
+func (t *TransformFeedback) Buffers() []*VertexBuffer {
+	return t.buffers
+}
+
+// Destroy deallocates the transform feedback object. The buffers are not
+// destroyed.
+func (t *TransformFeedback) Destroy() {
+	if t.id != 0 {
+		gl.DeleteTransformFeedbacks(1, &t.id)
+		t.id = 0
+	}
+	if t.query != 0 {
+		gl.DeleteQueries(1, &t.query)
+		t.query = 0
+	}
+}
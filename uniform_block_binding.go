@@ -0,0 +1,72 @@
+package render
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	blockBindingsMu sync.Mutex
+	blockBindings   = make(map[string]uint32)
+	// block names each binding point is assigned to, a name may own several
+	// binding points through shader overrides or reassignment
+	bindingOwners    = make(map[uint32]string)
+	nextBlockBinding uint32
+)
+
+// SetUniformBlockBinding registers the binding point used for every uniform
+// block of the provided name. It only affects programs linked afterwards, so
+// it should be called before any shaders are created. An error is returned if
+// the binding point is already assigned to a block of a different name.
+func SetUniformBlockBinding(name string, binding uint32) error {
+	blockBindingsMu.Lock()
+	defer blockBindingsMu.Unlock()
+	err := reserveBinding(name, binding)
+	if err != nil {
+		return err
+	}
+	// any previous binding point stays reserved, as programs linked earlier
+	// may still use it
+	blockBindings[name] = binding
+	return nil
+}
+
+// UniformBlockBinding returns the binding point registered for uniform blocks
+// of the provided name, assigning the next unused binding point if the name
+// has not been registered yet.
+func UniformBlockBinding(name string) uint32 {
+	blockBindingsMu.Lock()
+	defer blockBindingsMu.Unlock()
+	binding, ok := blockBindings[name]
+	if ok {
+		return binding
+	}
+	for {
+		if _, used := bindingOwners[nextBlockBinding]; !used {
+			break
+		}
+		nextBlockBinding++
+	}
+	binding = nextBlockBinding
+	blockBindings[name] = binding
+	bindingOwners[binding] = name
+	return binding
+}
+
+// reserveUniformBlockBinding reserves the binding point for blocks of the
+// provided name without changing the binding registered for the name.
+func reserveUniformBlockBinding(name string, binding uint32) error {
+	blockBindingsMu.Lock()
+	defer blockBindingsMu.Unlock()
+	return reserveBinding(name, binding)
+}
+
+func reserveBinding(name string, binding uint32) error {
+	owner, ok := bindingOwners[binding]
+	if ok && owner != name {
+		return fmt.Errorf("binding point %d is already assigned to uniform "+
+			"block `%s`", binding, owner)
+	}
+	bindingOwners[binding] = name
+	return nil
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"testing"
+)
+
+func resetBlockBindings() {
+	blockBindingsMu.Lock()
+	defer blockBindingsMu.Unlock()
+	blockBindings = make(map[string]uint32)
+	bindingOwners = make(map[uint32]string)
+	nextBlockBinding = 0
+}
+
+func TestUniformBlockBinding(t *testing.T) {
+	resetBlockBindings()
+	defer resetBlockBindings()
+
+	if err := SetUniformBlockBinding("Camera", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// automatic assignment skips explicit bindings
+	expected := map[string]uint32{
+		"Lights":   0,
+		"Camera":   1,
+		"Material": 2,
+	}
+	for _, name := range []string{"Lights", "Camera", "Material", "Lights"} {
+		if binding := UniformBlockBinding(name); binding != expected[name] {
+			t.Errorf("expected `%s` at binding %d, got %d", name, expected[name], binding)
+		}
+	}
+}
+
+func TestSetUniformBlockBindingCollision(t *testing.T) {
+	resetBlockBindings()
+	defer resetBlockBindings()
+
+	lights := UniformBlockBinding("Lights")
+	err := SetUniformBlockBinding("Camera", lights)
+	if err == nil {
+		t.Fatalf("expected error assigning a binding owned by another block")
+	}
+	// the same name may be reassigned to its own binding
+	if err := SetUniformBlockBinding("Lights", lights); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the previous binding stays reserved after reassignment
+	if err := SetUniformBlockBinding("Lights", 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SetUniformBlockBinding("Camera", lights); err == nil {
+		t.Fatalf("expected error assigning a binding still in use")
+	}
+	// shader overrides reserve their binding
+	if err := reserveUniformBlockBinding("Material", 6); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SetUniformBlockBinding("Camera", 6); err == nil {
+		t.Fatalf("expected error assigning a reserved binding")
+	}
+}
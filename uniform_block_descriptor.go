@@ -8,14 +8,19 @@ import (
 type UniformBlockDescriptor struct {
 	Name      string
 	Index     uint32
+	Binding   uint32
 	Size      int32
 	Offsets   map[string]int32
 	Alignment int32
+	// Members are the active members of the block, keyed by name.
+	Members map[string]*UniformBlockMember
+	// Stages are the shader stages that reference the block.
+	Stages []uint32
 }
 
 // AlignedSize returns the aligned block size of the uniform block.
 func (u *UniformBlockDescriptor) AlignedSize() int32 {
-	return u.Size + u.Alignment - (u.Size % u.Alignment)
+	return alignUp(u.Size, u.Alignment)
 }
 
 // UnAlignedSize returns the unaligned block size of the uniform block.
@@ -31,3 +36,11 @@ func (u *UniformBlockDescriptor) Offset(name string) (int32, error) {
 	}
 	return offset, nil
 }
+
+// alignUp rounds the size up to the next multiple of the alignment.
+func alignUp(size int32, alignment int32) int32 {
+	if alignment <= 0 || size%alignment == 0 {
+		return size
+	}
+	return size + alignment - (size % alignment)
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// UniformBlockMember represents a single uniform block members attributes.
+type UniformBlockMember struct {
+	Name string
+	// Type is the GL type of the member, such as gl.FLOAT_VEC3.
+	Type uint32
+	// Count is the array size of the member, 1 if not an array.
+	Count int32
+	// Offset is the byte offset of the member from the start of the block.
+	Offset int32
+	// ArrayStride is the byte stride between array elements, 0 if not an
+	// array.
+	ArrayStride int32
+	// MatrixStride is the byte stride between matrix columns, or rows if row
+	// major, 0 if not a matrix.
+	MatrixStride int32
+	// RowMajor is whether the member is a row major matrix.
+	RowMajor bool
+	// Order is the declared position of the member within the block.
+	Order int
+}
+
+// OrderedMembers returns the members of the uniform block in declared order.
+func (u *UniformBlockDescriptor) OrderedMembers() []*UniformBlockMember {
+	members := make([]*UniformBlockMember, 0, len(u.Members))
+	for _, member := range u.Members {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Order < members[j].Order
+	})
+	return members
+}
+
+// ReferencedBy returns whether the uniform block is referenced by the
+// provided shader stage, such as gl.VERTEX_SHADER.
+func (u *UniformBlockDescriptor) ReferencedBy(stage uint32) bool {
+	for _, typ := range u.Stages {
+		if typ == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// orderMembers assigns the declared order of block members. Block members
+// are laid out in declaration order, so sorting by offset recovers it.
+func orderMembers(members map[string]*UniformBlockMember) {
+	ordered := make([]*UniformBlockMember, 0, len(members))
+	for _, member := range members {
+		ordered = append(ordered, member)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Offset != ordered[j].Offset {
+			return ordered[i].Offset < ordered[j].Offset
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	for i, member := range ordered {
+		member.Order = i
+	}
+}
+
+// memberLayout returns the component base type, the number of components per
+// column and the number of columns of a block member type.
+func memberLayout(typ uint32) (uint32, int32, int32) {
+	switch typ {
+	case gl.BOOL:
+		return gl.BOOL, 1, 1
+	case gl.BOOL_VEC2:
+		return gl.BOOL, 2, 1
+	case gl.BOOL_VEC3:
+		return gl.BOOL, 3, 1
+	case gl.BOOL_VEC4:
+		return gl.BOOL, 4, 1
+	case gl.DOUBLE_MAT2:
+		return gl.DOUBLE, 2, 2
+	case gl.DOUBLE_MAT2x3:
+		return gl.DOUBLE, 3, 2
+	case gl.DOUBLE_MAT2x4:
+		return gl.DOUBLE, 4, 2
+	case gl.DOUBLE_MAT3:
+		return gl.DOUBLE, 3, 3
+	case gl.DOUBLE_MAT3x2:
+		return gl.DOUBLE, 2, 3
+	case gl.DOUBLE_MAT3x4:
+		return gl.DOUBLE, 4, 3
+	case gl.DOUBLE_MAT4:
+		return gl.DOUBLE, 4, 4
+	case gl.DOUBLE_MAT4x2:
+		return gl.DOUBLE, 2, 4
+	case gl.DOUBLE_MAT4x3:
+		return gl.DOUBLE, 3, 4
+	}
+	return attributeLayout(typ)
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// UniformBuffer represents a uniform buffer object. It is divided into a ring
+// of per-frame regions from which block slices are sub-allocated, so that the
+// slices of a frame are not overwritten while the GPU may still read them.
+type UniformBuffer struct {
+	id        uint32
+	data      []byte
+	alignment int32
+	frameSize int32
+	frames    int32
+	frame     int32
+	offset    int32
+}
+
+// NewUniformBuffer instantiates and returns a new uniform buffer with the
+// provided number of bytes per frame, for the provided number of frames.
+func NewUniformBuffer(frameSize int32, frames int32) *UniformBuffer {
+	if frames < 1 {
+		frames = 1
+	}
+	var alignment int32
+	gl.GetIntegerv(gl.UNIFORM_BUFFER_OFFSET_ALIGNMENT, &alignment)
+	frameSize = alignUp(frameSize, alignment)
+	u := &UniformBuffer{
+		data:      make([]byte, frameSize*frames),
+		alignment: alignment,
+		frameSize: frameSize,
+		frames:    frames,
+	}
+	gl.GenBuffers(1, &u.id)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, u.id)
+	gl.BufferData(gl.UNIFORM_BUFFER, len(u.data), nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	return u
+}
+
+// NextFrame advances to the next frame region of the ring, recycling the
+// slices allocated from it the last time it was used.
+func (u *UniformBuffer) NextFrame() {
+	u.frame = (u.frame + 1) % u.frames
+	u.offset = 0
+}
+
+// Allocate sub-allocates a slice large enough to hold the provided uniform
+// block from the current frame region.
+func (u *UniformBuffer) Allocate(block *UniformBlockDescriptor) (*UniformBlockSlice, error) {
+	offset := alignUp(u.offset, u.alignment)
+	if offset+block.Size > u.frameSize {
+		return nil, fmt.Errorf("uniform buffer frame of %d bytes cannot fit "+
+			"block `%s` of %d bytes at offset %d", u.frameSize, block.Name,
+			block.Size, offset)
+	}
+	u.offset = offset + block.Size
+	return &UniformBlockSlice{
+		buffer: u,
+		block:  block,
+		offset: u.frame*u.frameSize + offset,
+		size:   block.Size,
+	}, nil
+}
+
+// ID returns the ID of the uniform buffer.
+func (u *UniformBuffer) ID() uint32 {
+	return u.id
+}
+
+// Destroy deallocates the uniform buffer.
+func (u *UniformBuffer) Destroy() {
+	if u.id != 0 {
+		gl.DeleteBuffers(1, &u.id)
+		u.id = 0
+	}
+}
+
+// UniformBlockSlice represents a range of a uniform buffer holding the data
+// of a single uniform block.
+type UniformBlockSlice struct {
+	buffer *UniformBuffer
+	block  *UniformBlockDescriptor
+	offset int32
+	size   int32
+	dirty  bool
+}
+
+// Block returns the descriptor of the uniform block the slice was allocated
+// for.
+func (s *UniformBlockSlice) Block() *UniformBlockDescriptor {
+	return s.block
+}
+
+// Set writes the value of the named block member. Values are provided as for
+// SetUniform, with matrices in column-major order, and are written at the
+// reflected offset, array stride and matrix stride of the member. Arrays may
+// be named with or without their `[0]` suffix.
+func (s *UniformBlockSlice) Set(name string, value interface{}) error {
+	member, ok := s.block.Members[name]
+	if !ok {
+		member, ok = s.block.Members[name+"[0]"]
+		if !ok {
+			return fmt.Errorf("name `%s` not recognized in block `%s`",
+				name, s.block.Name)
+		}
+	}
+	base, rows, columns := memberLayout(member.Type)
+	words, size, n, err := blockWords(value, base, rows*columns, member.Count)
+	if err != nil {
+		return fmt.Errorf("failed to set block member `%s`: %v", name, err)
+	}
+	// compute the offset of each component
+	offsets := make([]int32, 0, len(words))
+	end := int32(0)
+	for i := int32(0); i < n; i++ {
+		element := member.Offset + i*member.ArrayStride
+		for c := int32(0); c < columns; c++ {
+			for r := int32(0); r < rows; r++ {
+				offset := element + c*member.MatrixStride + r*size
+				if member.RowMajor {
+					offset = element + r*member.MatrixStride + c*size
+				}
+				offsets = append(offsets, offset)
+				end = max32(end, offset+size)
+			}
+		}
+	}
+	if end > s.size {
+		return fmt.Errorf("block member `%s` ending at offset %d overflows "+
+			"block `%s` of %d bytes", name, end, s.block.Name, s.size)
+	}
+	data := s.buffer.data[s.offset:]
+	for i, word := range words {
+		if size == 8 {
+			binary.LittleEndian.PutUint64(data[offsets[i]:], word)
+		} else {
+			binary.LittleEndian.PutUint32(data[offsets[i]:], uint32(word))
+		}
+	}
+	s.dirty = true
+	return nil
+}
+
+// Bind uploads any pending writes and binds the slice to the provided
+// uniform buffer binding point.
+func (s *UniformBlockSlice) Bind(binding uint32) {
+	gl.BindBuffer(gl.UNIFORM_BUFFER, s.buffer.id)
+	if s.dirty {
+		gl.BufferSubData(
+			gl.UNIFORM_BUFFER,
+			int(s.offset),
+			int(s.size),
+			gl.Ptr(s.buffer.data[s.offset:]))
+		s.dirty = false
+	}
+	gl.BindBufferRange(
+		gl.UNIFORM_BUFFER,
+		binding,
+		s.buffer.id,
+		int(s.offset),
+		int(s.size))
+}
+
+// blockWords converts a value to the scalar base type of a block member,
+// returning the bits of each component, the byte size of each component and
+// the number of array elements provided.
+func blockWords(value interface{}, base uint32, components int32, count int32) ([]uint64, int32, int32, error) {
+	var words []uint64
+	switch base {
+	case gl.DOUBLE:
+		values, n, err := float64Values(value, components, count)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		for _, v := range values {
+			words = append(words, math.Float64bits(v))
+		}
+		return words, 8, n, nil
+	case gl.INT:
+		values, n, err := int32Values(value, components, count)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		for _, v := range values {
+			words = append(words, uint64(uint32(v)))
+		}
+		return words, 4, n, nil
+	case gl.UNSIGNED_INT, gl.BOOL:
+		values, n, err := uint32Values(value, components, count)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		for _, v := range values {
+			words = append(words, uint64(v))
+		}
+		return words, 4, n, nil
+	}
+	values, n, err := float32Values(value, components, count)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, v := range values {
+		words = append(words, uint64(math.Float32bits(v)))
+	}
+	return words, 4, n, nil
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+type stridedBlock struct {
+	Weights [3]float32    `glsl:"weights,array"`
+	Normal  [9]float32    `glsl:"normal"`
+	Points  [2][3]float32 `glsl:"points"`
+	Rotate  [4]float32    `glsl:"rotate,mat2"`
+	Count   int32         `glsl:"count"`
+}
+
+func newTestSlice(members map[string]*UniformBlockMember, size int32) *UniformBlockSlice {
+	return &UniformBlockSlice{
+		buffer: &UniformBuffer{
+			data:      make([]byte, size),
+			frameSize: size,
+			frames:    1,
+		},
+		block: &UniformBlockDescriptor{
+			Name:    "Block",
+			Size:    size,
+			Members: members,
+		},
+		size: size,
+	}
+}
+
+func TestUniformBlockSliceSetStrides(t *testing.T) {
+	value := stridedBlock{
+		Weights: [3]float32{1, 2, 3},
+		Normal:  [9]float32{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Points:  [2][3]float32{{1, 2, 3}, {4, 5, 6}},
+		Rotate:  [4]float32{1, 2, 3, 4},
+		Count:   7,
+	}
+	expected, err := EncodeStd140(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// members as reflected by GL for the equivalent std140 block
+	members := map[string]*UniformBlockMember{
+		"weights[0]": {Type: gl.FLOAT, Count: 3, Offset: 0, ArrayStride: 16},
+		"normal":     {Type: gl.FLOAT_MAT3, Count: 1, Offset: 48, MatrixStride: 16},
+		"points[0]":  {Type: gl.FLOAT_VEC3, Count: 2, Offset: 96, ArrayStride: 16},
+		"rotate":     {Type: gl.FLOAT_MAT2, Count: 1, Offset: 128, MatrixStride: 16},
+		"count":      {Type: gl.INT, Count: 1, Offset: 160},
+	}
+	slice := newTestSlice(members, int32(len(expected)))
+	values := map[string]interface{}{
+		"weights": value.Weights,
+		"normal":  value.Normal,
+		"points":  value.Points,
+		"rotate":  value.Rotate,
+		"count":   value.Count,
+	}
+	for name, v := range values {
+		if err := slice.Set(name, v); err != nil {
+			t.Fatalf("unexpected error setting `%s`: %v", name, err)
+		}
+	}
+	if !bytes.Equal(slice.buffer.data, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, slice.buffer.data)
+	}
+	if !slice.dirty {
+		t.Errorf("expected slice to be dirty")
+	}
+}
+
+func TestUniformBlockSliceSetRowMajor(t *testing.T) {
+	members := map[string]*UniformBlockMember{
+		// mat2x3 has 2 columns of 3 rows, stored as 3 rows of 2 columns
+		"m": {Type: gl.FLOAT_MAT2x3, Count: 1, Offset: 0, MatrixStride: 16, RowMajor: true},
+	}
+	slice := newTestSlice(members, 48)
+	err := slice.Set("m", []float32{1, 2, 3, 4, 5, 6})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// column c, row r is stored at r*stride + c*4
+	expected := map[int]float32{
+		0: 1, 16: 2, 32: 3,
+		4: 4, 20: 5, 36: 6,
+	}
+	for offset, v := range expected {
+		got := math.Float32frombits(binary.LittleEndian.Uint32(slice.buffer.data[offset:]))
+		if got != v {
+			t.Errorf("expected %v at offset %d, got %v", v, offset, got)
+		}
+	}
+}
+
+func TestUniformBlockSliceSetErrors(t *testing.T) {
+	members := map[string]*UniformBlockMember{
+		"v":    {Type: gl.FLOAT_VEC3, Count: 1, Offset: 0},
+		"a[0]": {Type: gl.FLOAT, Count: 2, Offset: 16, ArrayStride: 16},
+		"d":    {Type: gl.DOUBLE, Count: 1, Offset: 40},
+	}
+	slice := newTestSlice(members, 44)
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"missing", float32(1)},
+		{"v", []float32{1, 2}},
+		{"a", []float32{1, 2, 3}},
+		{"v", "1"},
+		// a double at offset 40 overflows the 44 byte block
+		{"d", 1.0},
+	}
+	for _, test := range tests {
+		if err := slice.Set(test.name, test.value); err == nil {
+			t.Errorf("expected error setting `%s` to %v", test.name, test.value)
+		}
+	}
+}
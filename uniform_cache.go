@@ -0,0 +1,139 @@
+package render
+
+import (
+	"math"
+)
+
+// UniformCacheStats represents the number of uniform uploads skipped and
+// performed by a shader.
+type UniformCacheStats struct {
+	// Hits is the number of uploads skipped as the value was unchanged.
+	Hits uint64
+	// Misses is the number of uploads performed.
+	Misses uint64
+}
+
+// UniformCacheStats returns the uniform cache counters of the shader.
+func (s *Shader) UniformCacheStats() UniformCacheStats {
+	return s.uniformStats
+}
+
+// ResetUniformCacheStats resets the uniform cache counters of the shader.
+func (s *Shader) ResetUniformCacheStats() {
+	s.uniformStats = UniformCacheStats{}
+}
+
+// InvalidateUniformCache clears the last uploaded uniform values so that the
+// next value set at each location is uploaded. This is only necessary if the
+// program uniforms are modified outside of the shader.
+func (s *Shader) InvalidateUniformCache() {
+	s.uniformCache = nil
+}
+
+// aliasUniform records that a location refers to an element within the array
+// uniform at the base location. Writes to an alias are never cached, and
+// invalidate the cached value of the base.
+func (s *Shader) aliasUniform(location int32, base int32) {
+	if location == -1 {
+		return
+	}
+	if s.uniformAliases == nil {
+		s.uniformAliases = make(map[int32]int32)
+	}
+	s.uniformAliases[location] = base
+}
+
+func (s *Shader) cachedInt32(location int32, values []int32) bool {
+	prev, ok := s.uniformCache[location].([]int32)
+	if ok && len(prev) == len(values) {
+		equal := true
+		for i, v := range values {
+			if prev[i] != v {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			s.uniformStats.Hits++
+			return true
+		}
+	}
+	s.storeUniform(location, append(prev[:0], values...))
+	return false
+}
+
+func (s *Shader) cachedUint32(location int32, values []uint32) bool {
+	prev, ok := s.uniformCache[location].([]uint32)
+	if ok && len(prev) == len(values) {
+		equal := true
+		for i, v := range values {
+			if prev[i] != v {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			s.uniformStats.Hits++
+			return true
+		}
+	}
+	s.storeUniform(location, append(prev[:0], values...))
+	return false
+}
+
+func (s *Shader) cachedFloat32(location int32, values []float32) bool {
+	prev, ok := s.uniformCache[location].([]float32)
+	if ok && len(prev) == len(values) {
+		// compare bits so that signed zeros and NaNs are not conflated
+		equal := true
+		for i, v := range values {
+			if math.Float32bits(prev[i]) != math.Float32bits(v) {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			s.uniformStats.Hits++
+			return true
+		}
+	}
+	s.storeUniform(location, append(prev[:0], values...))
+	return false
+}
+
+func (s *Shader) cachedFloat64(location int32, values []float64) bool {
+	prev, ok := s.uniformCache[location].([]float64)
+	if ok && len(prev) == len(values) {
+		// compare bits so that signed zeros and NaNs are not conflated
+		equal := true
+		for i, v := range values {
+			if math.Float64bits(prev[i]) != math.Float64bits(v) {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			s.uniformStats.Hits++
+			return true
+		}
+	}
+	s.storeUniform(location, append(prev[:0], values...))
+	return false
+}
+
+func (s *Shader) storeUniform(location int32, values interface{}) {
+	s.uniformStats.Misses++
+	if location == -1 {
+		return
+	}
+	base, ok := s.uniformAliases[location]
+	if ok {
+		// the element overwrote part of the cached array value
+		delete(s.uniformCache, base)
+		return
+	}
+	if s.uniformCache == nil {
+		s.uniformCache = make(map[int32]interface{})
+	}
+	s.uniformCache[location] = values
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCachedFloat32(t *testing.T) {
+	nan := float32(math.NaN())
+	negZero := float32(math.Copysign(0, -1))
+	tests := []struct {
+		name   string
+		writes [][]float32
+		cached []bool
+	}{
+		{"repeat", [][]float32{{1, 2}, {1, 2}}, []bool{false, true}},
+		{"changed", [][]float32{{1, 2}, {1, 3}}, []bool{false, false}},
+		{"changed back", [][]float32{{1, 2}, {1, 3}, {1, 2}}, []bool{false, false, false}},
+		{"length", [][]float32{{1, 2}, {1}}, []bool{false, false}},
+		{"nan", [][]float32{{nan}, {nan}}, []bool{false, true}},
+		{"signed zero", [][]float32{{0}, {negZero}, {negZero}}, []bool{false, false, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Shader{}
+			for i, values := range test.writes {
+				if cached := s.cachedFloat32(0, values); cached != test.cached[i] {
+					t.Errorf("write %d: expected cached %t, got %t", i, test.cached[i], cached)
+				}
+			}
+		})
+	}
+}
+
+func TestCachedValuesAreCopied(t *testing.T) {
+	s := &Shader{}
+	values := []int32{1, 2, 3}
+	s.cachedInt32(0, values)
+	// mutating the caller's slice must not update the cached value
+	values[0] = 4
+	if s.cachedInt32(0, values) {
+		t.Errorf("expected mutated values to be uploaded")
+	}
+}
+
+func TestCachedTypesAreDistinct(t *testing.T) {
+	s := &Shader{}
+	s.cachedInt32(0, []int32{1})
+	if s.cachedUint32(0, []uint32{1}) {
+		t.Errorf("expected a value of a different type to be uploaded")
+	}
+	if s.cachedFloat64(1, []float64{1}) {
+		t.Errorf("expected the first value to be uploaded")
+	}
+	if !s.cachedFloat64(1, []float64{1}) {
+		t.Errorf("expected a repeated value to be cached")
+	}
+}
+
+func TestUniformCacheLocations(t *testing.T) {
+	s := &Shader{}
+	// inactive uniforms are never cached
+	s.cachedFloat32(-1, []float32{1})
+	if s.cachedFloat32(-1, []float32{1}) {
+		t.Errorf("expected inactive location to be uploaded")
+	}
+	// writes to an element alias invalidate the array at the base location
+	s.aliasUniform(5, 4)
+	s.cachedFloat32(4, []float32{1, 2})
+	if !s.cachedFloat32(4, []float32{1, 2}) {
+		t.Errorf("expected repeated array to be cached")
+	}
+	if s.cachedFloat32(5, []float32{3}) || s.cachedFloat32(5, []float32{3}) {
+		t.Errorf("expected element writes to be uploaded")
+	}
+	if s.cachedFloat32(4, []float32{1, 2}) {
+		t.Errorf("expected array to be uploaded after an element write")
+	}
+	// invalidation forces the next write
+	s.InvalidateUniformCache()
+	if s.cachedFloat32(4, []float32{1, 2}) {
+		t.Errorf("expected array to be uploaded after invalidation")
+	}
+}
+
+func TestUniformCacheStats(t *testing.T) {
+	s := &Shader{}
+	s.cachedUint32(0, []uint32{1})
+	s.cachedUint32(0, []uint32{1})
+	s.cachedUint32(0, []uint32{2})
+	expected := UniformCacheStats{Hits: 1, Misses: 2}
+	if stats := s.UniformCacheStats(); stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+	s.ResetUniformCacheStats()
+	if stats := s.UniformCacheStats(); stats != (UniformCacheStats{}) {
+		t.Errorf("expected reset stats, got %+v", stats)
+	}
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type structKey struct {
+	typ  reflect.Type
+	name string
+}
+
+type structField struct {
+	index []int
+	// descriptor is set for fields bound directly to a uniform
+	descriptor *UniformDescriptor
+	// name is set for fields holding nested arrays or slices of structs
+	name string
+}
+
+// SetUniformStruct buffers the exported fields of a struct, or of each
+// element of an array or slice of structs, to the GLSL struct uniform of the
+// provided name. Fields are matched to members by their `glsl:"..."` tag, or
+// by their field name if untagged. A `glsl:"-"` tag skips the field. Members
+// that are not active in the program are skipped.
+func (s *Shader) SetUniformStruct(name string, arg interface{}) error {
+	known, ok := s.structNames[name]
+	if !ok {
+		known = s.hasStructUniform(name)
+		if s.structNames == nil {
+			s.structNames = make(map[string]bool)
+		}
+		s.structNames[name] = known
+	}
+	if !known {
+		return fmt.Errorf("struct uniform `%s` was not recognized", name)
+	}
+	return s.setStruct(name, reflect.ValueOf(arg))
+}
+
+func (s *Shader) setStruct(name string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fmt.Errorf("struct uniform `%s` is nil", name)
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			err := s.setStruct(name+"["+strconv.Itoa(i)+"]", v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return fmt.Errorf("%v of type %s is not a struct", v, v.Type())
+	}
+	for _, field := range s.structFields(name, v.Type()) {
+		value := v.FieldByIndex(field.index)
+		if field.descriptor == nil {
+			err := s.setStruct(field.name, value)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := s.setUniform(field.descriptor, value.Interface())
+		if err != nil {
+			return fmt.Errorf("failed to set uniform `%s`: %v",
+				field.descriptor.Name, err)
+		}
+	}
+	return nil
+}
+
+// structFields resolves the fields of a struct type against the descriptors
+// of the named uniform, caching the result.
+func (s *Shader) structFields(name string, typ reflect.Type) []structField {
+	key := structKey{
+		typ:  typ,
+		name: name,
+	}
+	fields, ok := s.structs[key]
+	if ok {
+		return fields
+	}
+	fields = s.resolveStructFields(name, typ, nil)
+	if s.structs == nil {
+		s.structs = make(map[structKey][]structField)
+	}
+	s.structs[key] = fields
+	return fields
+}
+
+func (s *Shader) resolveStructFields(name string, typ reflect.Type, index []int) []structField {
+	var fields []structField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tag := field.Tag.Get("glsl")
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		candidates := []string{tag}
+		if tag == "" {
+			candidates = []string{field.Name, lowerFirst(field.Name)}
+		}
+		for _, candidate := range candidates {
+			member := name + "." + candidate
+			// nested structs are flattened into the parent
+			if field.Type.Kind() == reflect.Struct && s.hasStructUniform(member) {
+				fields = append(fields, s.resolveStructFields(member, field.Type, fieldIndex)...)
+				break
+			}
+			// arrays and slices of structs are resolved per element
+			if isStructSequence(field.Type) && s.hasStructUniform(member) {
+				fields = append(fields, structField{
+					index: fieldIndex,
+					name:  member,
+				})
+				break
+			}
+			descriptor, ok := s.descriptor(member)
+			if ok {
+				fields = append(fields, structField{
+					index:      fieldIndex,
+					descriptor: descriptor,
+				})
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// descriptor returns the descriptor for the provided uniform name, resolving
+// arrays by their base name and individual array elements.
+func (s *Shader) descriptor(name string) (*UniformDescriptor, bool) {
+	descriptor, ok := s.descriptors[name]
+	if ok {
+		return descriptor, true
+	}
+	// arrays are reported with a `[0]` suffix
+	descriptor, ok = s.descriptors[name+"[0]"]
+	if ok {
+		return descriptor, true
+	}
+	// individual array elements
+	descriptor, ok = s.elements[name]
+	if ok {
+		return descriptor, true
+	}
+	if !strings.HasSuffix(name, "]") {
+		return nil, false
+	}
+	open := strings.LastIndex(name, "[")
+	if open == -1 {
+		return nil, false
+	}
+	element, err := strconv.Atoi(name[open+1 : len(name)-1])
+	if err != nil {
+		return nil, false
+	}
+	base, ok := s.descriptors[name[:open]+"[0]"]
+	if !ok || element <= 0 || int32(element) >= base.Count {
+		return nil, false
+	}
+	descriptor = &UniformDescriptor{
+		Name:     name,
+		Type:     base.Type,
+		Count:    base.Count - int32(element),
+		Location: s.queryUniformLocations([]string{name})[0],
+	}
+	if s.elements == nil {
+		s.elements = make(map[string]*UniformDescriptor)
+	}
+	s.elements[name] = descriptor
+	s.aliasUniform(descriptor.Location, base.Location)
+	return descriptor, true
+}
+
+// hasStructUniform returns whether any active uniform is a member of the
+// named struct, or of an element of the named array of structs.
+func (s *Shader) hasStructUniform(name string) bool {
+	for uniform := range s.descriptors {
+		if strings.HasPrefix(uniform, name+".") || strings.HasPrefix(uniform, name+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// isStructValue returns whether the value is a struct, or an array or slice
+// of structs.
+func isStructValue(arg interface{}) bool {
+	typ := reflect.TypeOf(arg)
+	if typ == nil {
+		return false
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct || isStructSequence(typ)
+}
+
+func isStructSequence(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Array && typ.Kind() != reflect.Slice {
+		return false
+	}
+	elem := typ.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+func lowerFirst(str string) string {
+	r, size := utf8.DecodeRuneInString(str)
+	return string(unicode.ToLower(r)) + str[size:]
+}
@@ -0,0 +1,227 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// float32Values flattens a scalar, array or slice of floats into a slice of
+// float32, returning the number of elements of the provided component size
+// it contains.
+func float32Values(arg interface{}, components int32, count int32) ([]float32, int32, error) {
+	var values []float32
+	// fast paths for common types
+	switch v := arg.(type) {
+	case float32:
+		values = []float32{v}
+	case []float32:
+		values = v
+	default:
+		err := checkPointer(arg, components*count)
+		if err != nil {
+			return nil, 0, err
+		}
+		values = make([]float32, 0, components*count)
+		err = flatten(arg, reflect.ValueOf(arg), func(v reflect.Value) bool {
+			switch v.Kind() {
+			case reflect.Float32, reflect.Float64:
+				values = append(values, float32(v.Float()))
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	n, err := checkLength(len(values), components, count)
+	return values, n, err
+}
+
+// float64Values flattens a scalar, array or slice of floats into a slice of
+// float64, returning the number of elements of the provided component size
+// it contains.
+func float64Values(arg interface{}, components int32, count int32) ([]float64, int32, error) {
+	var values []float64
+	// fast paths for common types
+	switch v := arg.(type) {
+	case float64:
+		values = []float64{v}
+	case []float64:
+		values = v
+	default:
+		err := checkPointer(arg, components*count)
+		if err != nil {
+			return nil, 0, err
+		}
+		values = make([]float64, 0, components*count)
+		err = flatten(arg, reflect.ValueOf(arg), func(v reflect.Value) bool {
+			switch v.Kind() {
+			case reflect.Float32, reflect.Float64:
+				values = append(values, v.Float())
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	n, err := checkLength(len(values), components, count)
+	return values, n, err
+}
+
+// int32Values flattens a scalar, array or slice of integers or booleans into
+// a slice of int32, returning the number of elements of the provided
+// component size it contains. Integers that do not fit in an int32 are
+// rejected.
+func int32Values(arg interface{}, components int32, count int32) ([]int32, int32, error) {
+	var values []int32
+	// fast paths for common types
+	switch v := arg.(type) {
+	case int32:
+		values = []int32{v}
+	case []int32:
+		values = v
+	default:
+		err := checkPointer(arg, components*count)
+		if err != nil {
+			return nil, 0, err
+		}
+		values = make([]int32, 0, components*count)
+		err = flatten(arg, reflect.ValueOf(arg), func(v reflect.Value) bool {
+			switch v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if v.Int() < math.MinInt32 || v.Int() > math.MaxInt32 {
+					return false
+				}
+				values = append(values, int32(v.Int()))
+				return true
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if v.Uint() > math.MaxInt32 {
+					return false
+				}
+				values = append(values, int32(v.Uint()))
+				return true
+			case reflect.Bool:
+				values = append(values, boolToInt32(v.Bool()))
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	n, err := checkLength(len(values), components, count)
+	return values, n, err
+}
+
+// uint32Values flattens a scalar, array or slice of unsigned integers or
+// booleans into a slice of uint32, returning the number of elements of the
+// provided component size it contains. Integers that do not fit in a uint32
+// are rejected.
+func uint32Values(arg interface{}, components int32, count int32) ([]uint32, int32, error) {
+	var values []uint32
+	// fast paths for common types
+	switch v := arg.(type) {
+	case uint32:
+		values = []uint32{v}
+	case []uint32:
+		values = v
+	default:
+		err := checkPointer(arg, components*count)
+		if err != nil {
+			return nil, 0, err
+		}
+		values = make([]uint32, 0, components*count)
+		err = flatten(arg, reflect.ValueOf(arg), func(v reflect.Value) bool {
+			switch v.Kind() {
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if v.Uint() > math.MaxUint32 {
+					return false
+				}
+				values = append(values, uint32(v.Uint()))
+				return true
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if v.Int() < 0 || v.Int() > math.MaxUint32 {
+					return false
+				}
+				values = append(values, uint32(v.Int()))
+				return true
+			case reflect.Bool:
+				values = append(values, uint32(boolToInt32(v.Bool())))
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	n, err := checkLength(len(values), components, count)
+	return values, n, err
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// flatten walks arrays, slices and pointers, passing each scalar to the
+// provided function.
+func flatten(arg interface{}, v reflect.Value, scalar func(reflect.Value) bool) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("%T is nil", arg)
+		}
+		return flatten(arg, v.Elem(), scalar)
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			err := flatten(arg, v.Index(i), scalar)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !v.IsValid() || !scalar(v) {
+		return fmt.Errorf("%v of type %T is not a valid value, or is out of "+
+			"range", arg, arg)
+	}
+	return nil
+}
+
+// checkPointer rejects pointers to single scalars when more than one value is
+// expected, as the length of the memory they point to cannot be validated.
+func checkPointer(arg interface{}, expected int32) error {
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Ptr || expected <= 1 {
+		return nil
+	}
+	switch v.Type().Elem().Kind() {
+	case reflect.Array, reflect.Slice:
+		return nil
+	}
+	return fmt.Errorf("cannot determine the number of values behind %T, "+
+		"provide an array or slice instead", arg)
+}
+
+func checkLength(n int, components int32, count int32) (int32, error) {
+	if n == 0 {
+		return 0, fmt.Errorf("no values provided")
+	}
+	if n%int(components) != 0 {
+		return 0, fmt.Errorf("%d values provided, expected a multiple of %d",
+			n, components)
+	}
+	if n > int(components*count) {
+		return 0, fmt.Errorf("%d values provided, expected at most %d "+
+			"(%d x %d components)", n, components*count, count, components)
+	}
+	return int32(n) / components, nil
+}
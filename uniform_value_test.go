@@ -0,0 +1,158 @@
+package render
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCheckLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		n          int
+		components int32
+		count      int32
+		expected   int32
+		err        string
+	}{
+		{"single", 4, 4, 1, 1, ""},
+		{"full array", 12, 3, 4, 4, ""},
+		{"partial array", 6, 3, 4, 2, ""},
+		{"empty", 0, 4, 1, 0, "no values provided"},
+		{"partial element", 5, 4, 2, 0, "5 values provided, expected a multiple of 4"},
+		{"too many", 12, 4, 2, 0, "12 values provided, expected at most 8 (2 x 4 components)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := checkLength(test.n, test.components, test.count)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != test.expected {
+				t.Errorf("expected %d elements, got %d", test.expected, n)
+			}
+		})
+	}
+}
+
+func TestFloat32Values(t *testing.T) {
+	vec3 := [3]float32{1, 2, 3}
+	var nilSlice *[]float32
+	tests := []struct {
+		name       string
+		arg        interface{}
+		components int32
+		count      int32
+		values     []float32
+		n          int32
+		err        bool
+	}{
+		{"scalar", float32(1), 1, 1, []float32{1}, 1, false},
+		{"float64 scalar", 2.5, 1, 1, []float32{2.5}, 1, false},
+		{"slice", []float32{1, 2, 3}, 3, 1, []float32{1, 2, 3}, 1, false},
+		{"array", vec3, 3, 1, []float32{1, 2, 3}, 1, false},
+		{"array pointer", &vec3, 3, 1, []float32{1, 2, 3}, 1, false},
+		{"array of arrays", [][3]float32{{1, 2, 3}, {4, 5, 6}}, 3, 2, []float32{1, 2, 3, 4, 5, 6}, 2, false},
+		{"partial array", [][2]float64{{1, 2}}, 2, 4, []float32{1, 2}, 1, false},
+		{"wrong components", []float32{1, 2}, 3, 1, nil, 0, true},
+		{"too many elements", [][2]float32{{1, 2}, {3, 4}}, 2, 1, nil, 0, true},
+		{"scalar pointer", new(float32), 4, 1, nil, 0, true},
+		{"nil pointer", nilSlice, 3, 1, nil, 0, true},
+		{"nil", nil, 1, 1, nil, 0, true},
+		{"wrong type", "1.0", 1, 1, nil, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, n, err := float32Values(test.arg, test.components, test.count)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, test.values) || n != test.n {
+				t.Errorf("expected %v (%d), got %v (%d)", test.values, test.n, values, n)
+			}
+		})
+	}
+}
+
+func TestInt32Values(t *testing.T) {
+	tests := []struct {
+		name   string
+		arg    interface{}
+		values []int32
+		err    bool
+	}{
+		{"int32", int32(-3), []int32{-3}, false},
+		{"int", []int{1, 2}, []int32{1, 2}, false},
+		{"uint8", [2]uint8{1, 2}, []int32{1, 2}, false},
+		{"bool", [2]bool{true, false}, []int32{1, 0}, false},
+		{"float", []float32{1, 2}, nil, true},
+		{"int64 bounds", []int64{math.MinInt32, math.MaxInt32}, []int32{math.MinInt32, math.MaxInt32}, false},
+		{"int64 overflow", []int64{1, math.MaxInt32 + 1}, nil, true},
+		{"int64 underflow", int64(math.MinInt32 - 1), nil, true},
+		{"uint32 overflow", []uint32{1, math.MaxInt32 + 1}, nil, true},
+		{"uint64 overflow", uint64(math.MaxUint64), nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, _, err := int32Values(test.arg, 1, 2)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, test.values) {
+				t.Errorf("expected %v, got %v", test.values, values)
+			}
+		})
+	}
+}
+
+func TestUint32Values(t *testing.T) {
+	tests := []struct {
+		name   string
+		arg    interface{}
+		values []uint32
+		err    bool
+	}{
+		{"uint32", uint32(3), []uint32{3}, false},
+		{"int", []int{1, 2}, []uint32{1, 2}, false},
+		{"bool", []bool{false, true}, []uint32{0, 1}, false},
+		{"negative", []int{1, -2}, nil, true},
+		{"uint64 bounds", []uint64{0, math.MaxUint32}, []uint32{0, math.MaxUint32}, false},
+		{"uint64 overflow", []uint64{1, math.MaxUint32 + 1}, nil, true},
+		{"int64 overflow", int64(math.MaxUint32 + 1), nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, _, err := uint32Values(test.arg, 1, 2)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, test.values) {
+				t.Errorf("expected %v, got %v", test.values, values)
+			}
+		})
+	}
+}
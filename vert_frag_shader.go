@@ -6,25 +6,8 @@ import (
 
 // NewVertFragShader instantiates a new shader object.
 func NewVertFragShader(vert, frag string) (*Shader, error) {
-	// create shader
-	shader := &Shader{}
-	// create vertex shader
-	vertex, err := shader.CreateShader(vert, gl.VERTEX_SHADER)
-	if err != nil {
-		return nil, err
-	}
-	// create fragment shader
-	fragment, err := shader.CreateShader(frag, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return nil, err
-	}
-	// attach shaders
-	shader.AttachShader(vertex)
-	shader.AttachShader(fragment)
-	// link program
-	err = shader.LinkProgram()
-	if err != nil {
-		return nil, err
-	}
-	return shader, nil
+	return NewShaderProgram([]*ShaderStage{
+		{Type: gl.VERTEX_SHADER, Source: vert},
+		{Type: gl.FRAGMENT_SHADER, Source: frag},
+	})
 }
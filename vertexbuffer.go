@@ -37,6 +37,13 @@ func (v *VertexBuffer) BufferSubFloat32(data []float32, offset int) {
 	gl.BufferSubData(gl.ARRAY_BUFFER, offset, len(data)*4, gl.Ptr(data))
 }
 
+// ReadSubFloat32 reads a portion of the underlying buffer into a float32
+// slice.
+func (v *VertexBuffer) ReadSubFloat32(data []float32, offset int) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, v.id)
+	gl.GetBufferSubData(gl.ARRAY_BUFFER, offset, len(data)*4, gl.Ptr(data))
+}
+
 // Bind binds the vertexbuffer.
 func (v *VertexBuffer) Bind() {
 	gl.BindBuffer(gl.ARRAY_BUFFER, v.id)